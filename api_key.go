@@ -0,0 +1,134 @@
+package router
+
+import (
+	"context"
+	"net/http"
+)
+
+// An APIKeyLookup returns the scopes granted to an API key.
+// The found return value is false if the key is unknown.
+type APIKeyLookup interface {
+	Lookup(key string) (scopes []string, found bool)
+}
+
+// APIKeyLookupFunc is a function that implements APIKeyLookup.
+type APIKeyLookupFunc func(key string) ([]string, bool)
+
+// Lookup calls fn(key).
+func (fn APIKeyLookupFunc) Lookup(key string) ([]string, bool) {
+	return fn(key)
+}
+
+// StaticAPIKeys is an APIKeyLookup that maps API keys to their scopes.
+type StaticAPIKeys map[string][]string
+
+// Lookup returns the scopes for key.
+func (s StaticAPIKeys) Lookup(key string) ([]string, bool) {
+	scopes, ok := s[key]
+	return scopes, ok
+}
+
+// An APIKeyExtractor returns the API key used in a request, or "" if there is none.
+type APIKeyExtractor func(r *http.Request) string
+
+// APIKeyFromHeader returns an APIKeyExtractor that reads the API key from the specified header.
+func APIKeyFromHeader(header string) APIKeyExtractor {
+	return func(r *http.Request) string {
+		return r.Header.Get(header)
+	}
+}
+
+// APIKeyFromQuery returns an APIKeyExtractor that reads the API key from the specified query parameter.
+func APIKeyFromQuery(param string) APIKeyExtractor {
+	return func(r *http.Request) string {
+		return r.URL.Query().Get(param)
+	}
+}
+
+// APIKeyFromCookie returns an APIKeyExtractor that reads the API key from the specified cookie.
+func APIKeyFromCookie(name string) APIKeyExtractor {
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+
+		return cookie.Value
+	}
+}
+
+// APIKeyMiddleware returns a Middleware that requires requests to use an API key known by lookup
+// before the original handler is executed.
+// The key is read from the request using extract.
+// The key's scopes can be fetched using APIKeyScopes, and checked using RequireScopes.
+// Otherwise, a 401 Status Unauthorized response is returned.
+func APIKeyMiddleware(lookup APIKeyLookup, extract APIKeyExtractor) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := extract(r)
+			if key == "" {
+				http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			scopes, ok := lookup.Lookup(key)
+			if !ok {
+				http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), apiKeyScopesKey, scopes)
+			handler.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// APIKeyScopes returns the scopes of the API key authenticated by APIKeyMiddleware.
+func APIKeyScopes(r *http.Request) ([]string, bool) {
+	scopes, ok := r.Context().Value(apiKeyScopesKey).([]string)
+	return scopes, ok
+}
+
+// RequireScopes returns a Middleware that requires the request's API key to have each of the specified scopes
+// before the original handler is executed.
+// It must be applied inside APIKeyMiddleware, which allows the required scopes
+// to be declared per route when building a RouteMap:
+//   rm := RouteMap{
+//           "/products": MethodHandlers{
+//                   http.MethodGet:  RequireScopes("products:read")(ListProducts),
+//                   http.MethodPost: RequireScopes("products:write")(AddProduct),
+//           },
+//   }
+//   rm.ApplyMiddleware(APIKeyMiddleware(keys, APIKeyFromHeader("X-API-Key")))
+// If the request was not authenticated, a 401 Status Unauthorized response is returned.
+// If the API key is missing a scope, a 403 Status Forbidden response is returned.
+func RequireScopes(scopes ...string) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			granted, ok := APIKeyScopes(r)
+			if !ok {
+				http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			for _, scope := range scopes {
+				if !containsString(granted, scope) {
+					http.Error(w, "403 Forbidden", http.StatusForbidden)
+					return
+				}
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,100 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyExtractors(t *testing.T) {
+	r := &http.Request{
+		Header: http.Header{},
+		URL:    &url.URL{RawQuery: "api_key=query"},
+	}
+
+	r.Header.Set("X-API-Key", "header")
+	r.AddCookie(&http.Cookie{Name: "api_key", Value: "cookie"})
+
+	assert.Equal(t, "header", APIKeyFromHeader("X-API-Key")(r))
+	assert.Equal(t, "query", APIKeyFromQuery("api_key")(r))
+	assert.Equal(t, "cookie", APIKeyFromCookie("api_key")(r))
+	assert.Equal(t, "", APIKeyFromCookie("missing")(r))
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	keys := StaticAPIKeys{
+		"reader": []string{"products:read"},
+		"writer": []string{"products:read", "products:write"},
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cases := map[string]struct {
+		Key      string
+		Scopes   []string
+		Expected int
+	}{
+		"Missing key": {
+			Key:      "",
+			Expected: http.StatusUnauthorized,
+		},
+		"Unknown key": {
+			Key:      "unknown",
+			Expected: http.StatusUnauthorized,
+		},
+		"Known key": {
+			Key:      "reader",
+			Expected: http.StatusOK,
+		},
+		"Sufficient scope": {
+			Key:      "writer",
+			Scopes:   []string{"products:read", "products:write"},
+			Expected: http.StatusOK,
+		},
+		"Insufficient scope": {
+			Key:      "reader",
+			Scopes:   []string{"products:write"},
+			Expected: http.StatusForbidden,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			r := &http.Request{Header: http.Header{}}
+			r.Header.Set("X-API-Key", c.Key)
+
+			h := APIKeyMiddleware(keys, APIKeyFromHeader("X-API-Key"))(RequireScopes(c.Scopes...)(handler))
+			h.ServeHTTP(recorder, r)
+			assert.Equal(t, c.Expected, recorder.Code)
+		})
+	}
+}
+
+func TestRequireScopesUnauthenticated(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	RequireScopes()(nil).ServeHTTP(recorder, NewRequest("GET", "/"))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	var scopes []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scopes, _ = APIKeyScopes(r)
+	})
+
+	lookup := APIKeyLookupFunc(func(key string) ([]string, bool) {
+		return []string{"admin"}, key == "secret"
+	})
+
+	r := &http.Request{Header: http.Header{}}
+	r.Header.Set("X-API-Key", "secret")
+
+	APIKeyMiddleware(lookup, APIKeyFromHeader("X-API-Key"))(handler).ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, []string{"admin"}, scopes)
+}
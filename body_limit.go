@@ -0,0 +1,43 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// BodyLimitMiddleware returns a Middleware that limits the size of request bodies to limit bytes.
+// Routes can use a different limit by adding their pattern to overrides, for example:
+//   BodyLimitMiddleware(1<<20, map[string]int64{"/uploads": 100 << 20})
+// Overrides require the matched route, so they only apply when BodyLimitMiddleware
+// is applied using RouteMap.ApplyMiddleware or Router.MatchMiddleware.
+// Requests with a Content-Length larger than the limit are rejected with a 413 Request Entity Too Large response.
+// Otherwise, the body is wrapped with http.MaxBytesReader,
+// so reads past the limit return an *http.MaxBytesError.
+// The limit is reported in the response's X-Body-Limit header.
+func BodyLimitMiddleware(limit int64, overrides map[string]int64) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			max := limit
+			if route, ok := MatchedRoute(r); ok {
+				if override, ok := overrides[route.Pattern]; ok {
+					max = override
+				}
+			}
+
+			w.Header().Set("X-Body-Limit", strconv.FormatInt(max, 10))
+			if r.ContentLength > max {
+				w.Header().Set("Connection", "close")
+				msg := fmt.Sprintf("413 Request Entity Too Large: request body must not exceed %d bytes", max)
+				http.Error(w, msg, http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, max)
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
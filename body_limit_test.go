@@ -0,0 +1,80 @@
+package router
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	var readErr error
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = ioutil.ReadAll(r.Body)
+	})
+
+	cases := map[string]struct {
+		Body          string
+		ContentLength int64
+		Status        int
+		ReadErr       bool
+	}{
+		"Within limit": {
+			Body:          "12345",
+			ContentLength: 5,
+			Status:        http.StatusOK,
+		},
+		"Content-Length too large": {
+			Body:          "123456",
+			ContentLength: 6,
+			Status:        http.StatusRequestEntityTooLarge,
+		},
+		"Unknown length too large": {
+			Body:          "123456",
+			ContentLength: -1,
+			Status:        http.StatusOK,
+			ReadErr:       true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			readErr = nil
+			r := httptest.NewRequest("POST", "/", strings.NewReader(c.Body))
+			r.ContentLength = c.ContentLength
+
+			recorder := httptest.NewRecorder()
+			BodyLimitMiddleware(5, nil)(handler).ServeHTTP(recorder, r)
+			assert.Equal(t, c.Status, recorder.Code)
+			assert.Equal(t, "5", recorder.Header().Get("X-Body-Limit"))
+
+			var maxBytesErr *http.MaxBytesError
+			assert.Equal(t, c.ReadErr, errors.As(readErr, &maxBytesErr))
+		})
+	}
+}
+
+func TestBodyLimitMiddlewareOverrides(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	rm := RouteMap{
+		"/small": MethodHandlers{http.MethodPost: handler},
+		"/large": MethodHandlers{http.MethodPost: handler},
+	}
+
+	rm.ApplyMiddleware(BodyLimitMiddleware(5, map[string]int64{"/large": 10}))
+	router := NewRouter(rm.StringMatch())
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("POST", "/small", strings.NewReader("123456")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "5 bytes")
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("POST", "/large", strings.NewReader("123456")))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "10", recorder.Header().Get("X-Body-Limit"))
+}
@@ -0,0 +1,426 @@
+package router
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a response stored by CacheMiddleware.
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// Stored is the time the response was stored.
+	Stored time.Time
+	// TTL is how long the response is fresh for.
+	TTL time.Duration
+	// StaleWhileRevalidate is how long a stale response can be served while it is revalidated in the background.
+	StaleWhileRevalidate time.Duration
+	// Vary are the request headers the response varies by.
+	Vary []string
+}
+
+// A Cache stores responses for CacheMiddleware.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, response *CachedResponse)
+}
+
+// MemoryCache is a Cache that keeps up to a fixed number of responses in memory,
+// evicting the least recently used response when full.
+type MemoryCache struct {
+	capacity int
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type memoryCacheEntry struct {
+	key      string
+	response *CachedResponse
+}
+
+// NewMemoryCache returns an empty MemoryCache that holds up to capacity responses.
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Get returns the response stored for key.
+func (c *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return element.Value.(*memoryCacheEntry).response, true
+}
+
+// Set stores response for key.
+func (c *MemoryCache) Set(key string, response *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*memoryCacheEntry).response = response
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryCacheEntry{key: key, response: response})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len returns the number of responses in c.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// CacheConfig configures CacheMiddleware.
+type CacheConfig struct {
+	// DefaultTTL is how long responses without a max-age or s-maxage directive are cached.
+	DefaultTTL time.Duration
+	// MaxBodySize is the largest response body, in bytes, that is cached.
+	// Larger responses are streamed without being cached. Defaults to 1MB.
+	MaxBodySize int64
+}
+
+// CacheMiddleware returns a Middleware that stores successful GET responses in cache.
+// Responses are keyed by method, host, URL, and the values of the request headers named in their Vary header.
+// The response's Cache-Control max-age, s-maxage and stale-while-revalidate directives
+// determine how long it is cached; responses without a max-age are cached for the config's DefaultTTL.
+// Responses with Cache-Control no-store, no-cache or private, with Vary: *, or with a Set-Cookie header are not cached,
+// and requests with Cache-Control no-store or no-cache bypass the cache.
+// As described in RFC 7234 section 3.2, requests with an Authorization header only use the cache
+// for responses with Cache-Control public or s-maxage.
+// A stale response within its stale-while-revalidate window is served
+// while the handler is executed in the background to refresh it.
+// Each response has an X-Cache header of HIT or MISS.
+func CacheMiddleware(cache Cache, config CacheConfig) Middleware {
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 1 << 20
+	}
+
+	var mu sync.Mutex
+	revalidating := map[string]bool{}
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			directives := parseCacheControl(r.Header.Get("Cache-Control"))
+			_, noStore := directives["no-store"]
+			_, noCache := directives["no-cache"]
+			if noStore {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			primary := r.Method + " " + r.Host + r.URL.RequestURI()
+			key := primary
+			if cached, ok := cache.Get(primary); ok && len(cached.Vary) > 0 {
+				key = varyKey(primary, cached.Vary, r)
+			}
+
+			if cached, ok := cache.Get(key); ok && cached.Status != 0 && !noCache && authorizedToUse(r, cached.Header) {
+				age := time.Since(cached.Stored)
+				fresh := age < cached.TTL
+				stale := !fresh && age < cached.TTL+cached.StaleWhileRevalidate
+				if fresh || stale {
+					if stale {
+						mu.Lock()
+						start := !revalidating[key]
+						revalidating[key] = true
+						mu.Unlock()
+
+						if start {
+							go func() {
+								defer func() {
+									mu.Lock()
+									delete(revalidating, key)
+									mu.Unlock()
+								}()
+
+								rec := newCacheRecorder(&discardWriter{header: http.Header{}}, config.MaxBodySize)
+								handler.ServeHTTP(rec, r.Clone(context.WithoutCancel(r.Context())))
+								rec.sendHeader()
+								storeResponse(cache, primary, r, rec, config.DefaultTTL)
+							}()
+						}
+					}
+
+					writeCachedResponse(w, cached, age)
+					return
+				}
+			}
+
+			w.Header().Set("X-Cache", "MISS")
+			rec := newCacheRecorder(w, config.MaxBodySize)
+			handler.ServeHTTP(rec, r)
+			rec.sendHeader()
+			storeResponse(cache, primary, r, rec, config.DefaultTTL)
+		})
+	}
+}
+
+// writeCachedResponse writes cached to w.
+// Headers already set by outer middleware are not overwritten by the cached headers.
+func writeCachedResponse(w http.ResponseWriter, cached *CachedResponse, age time.Duration) {
+	header := w.Header()
+	for key, values := range cached.Header {
+		if _, ok := header[key]; !ok {
+			header[key] = append([]string{}, values...)
+		}
+	}
+
+	header.Set("Age", strconv.Itoa(int(age.Seconds())))
+	header.Set("X-Cache", "HIT")
+	w.WriteHeader(cached.Status)
+	w.Write(cached.Body)
+}
+
+// storeResponse stores the response recorded by rec if it is cacheable.
+func storeResponse(cache Cache, primary string, r *http.Request, rec *cacheRecorder, defaultTTL time.Duration) {
+	if rec.Status() != http.StatusOK || rec.overflow {
+		return
+	}
+
+	header := cloneHeader(rec.sent)
+
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return
+	}
+
+	if _, ok := directives["private"]; ok {
+		return
+	}
+
+	if _, ok := directives["no-cache"]; ok {
+		// no-cache responses must be revalidated before every use
+		return
+	}
+
+	if _, ok := header["Set-Cookie"]; ok {
+		return
+	}
+
+	if !authorizedToUse(r, header) {
+		return
+	}
+
+	vary := splitHeaderList(header["Vary"])
+	for i, name := range vary {
+		if name == "*" {
+			return
+		}
+
+		vary[i] = http.CanonicalHeaderKey(name)
+	}
+
+	sort.Strings(vary)
+
+	response := &CachedResponse{
+		Status: rec.Status(),
+		Header: header,
+		Body:   rec.body.Bytes(),
+		Stored: time.Now(),
+		TTL:    defaultTTL,
+		Vary:   vary,
+	}
+
+	if maxAge, ok := directiveSeconds(directives, "s-maxage"); ok {
+		response.TTL = maxAge
+	} else if maxAge, ok := directiveSeconds(directives, "max-age"); ok {
+		response.TTL = maxAge
+	}
+
+	if swr, ok := directiveSeconds(directives, "stale-while-revalidate"); ok {
+		response.StaleWhileRevalidate = swr
+	}
+
+	if _, ok := directives["must-revalidate"]; ok && response.TTL <= 0 {
+		// must-revalidate forbids serving the response once it is stale
+		return
+	}
+
+	if response.TTL <= 0 && response.StaleWhileRevalidate <= 0 {
+		return
+	}
+
+	if len(vary) == 0 {
+		cache.Set(primary, response)
+		return
+	}
+
+	cache.Set(primary, &CachedResponse{Vary: vary})
+	cache.Set(varyKey(primary, vary, r), response)
+}
+
+// authorizedToUse reports whether a response with header can be stored for, or served to, r.
+// Responses to requests with an Authorization header must be explicitly marked as shareable.
+func authorizedToUse(r *http.Request, header http.Header) bool {
+	if r.Header.Get("Authorization") == "" {
+		return true
+	}
+
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	_, public := directives["public"]
+	_, sMaxAge := directives["s-maxage"]
+	return public || sMaxAge
+}
+
+func varyKey(primary string, vary []string, r *http.Request) string {
+	parts := []string{primary}
+	for _, name := range vary {
+		parts = append(parts, name+"="+strings.Join(r.Header[name], ","))
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// parseCacheControl parses a Cache-Control header value into its directives.
+func parseCacheControl(value string) map[string]string {
+	directives := map[string]string{}
+	for _, directive := range strings.Split(value, ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "" {
+			continue
+		}
+
+		parts := strings.SplitN(directive, "=", 2)
+		name := strings.ToLower(parts[0])
+		if len(parts) == 2 {
+			directives[name] = strings.Trim(parts[1], `"`)
+		} else {
+			directives[name] = ""
+		}
+	}
+
+	return directives
+}
+
+func directiveSeconds(directives map[string]string, name string) (time.Duration, bool) {
+	value, ok := directives[name]
+	if !ok {
+		return 0, false
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+func cloneHeader(h http.Header) http.Header {
+	clone := http.Header{}
+	for key, values := range h {
+		clone[key] = append([]string{}, values...)
+	}
+
+	return clone
+}
+
+// cacheRecorder records a response, up to max bytes, while writing it to the underlying http.ResponseWriter.
+// The handler is given its own header map, so that only the headers it sets are recorded,
+// and not those set by outer middleware.
+type cacheRecorder struct {
+	*statusWriter
+	header   http.Header
+	sent     http.Header
+	max      int64
+	body     bytes.Buffer
+	overflow bool
+}
+
+func newCacheRecorder(w http.ResponseWriter, max int64) *cacheRecorder {
+	return &cacheRecorder{statusWriter: newStatusWriter(w), header: http.Header{}, max: max}
+}
+
+// Header returns the handler's header map.
+func (rec *cacheRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *cacheRecorder) WriteHeader(status int) {
+	rec.sendHeader()
+	rec.statusWriter.WriteHeader(status)
+}
+
+func (rec *cacheRecorder) Write(p []byte) (int, error) {
+	rec.sendHeader()
+	n, err := rec.statusWriter.Write(p)
+	if !rec.overflow {
+		if int64(rec.body.Len()+n) > rec.max {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(p[:n])
+		}
+	}
+
+	return n, err
+}
+
+// Flush implements http.Flusher if the underlying http.ResponseWriter does.
+func (rec *cacheRecorder) Flush() {
+	rec.sendHeader()
+	rec.statusWriter.Flush()
+}
+
+// sendHeader records the handler's headers and merges them into the underlying header map,
+// the first time the response is written.
+func (rec *cacheRecorder) sendHeader() {
+	if rec.sent != nil {
+		return
+	}
+
+	rec.sent = cloneHeader(rec.header)
+	dst := rec.statusWriter.Header()
+	for key, values := range rec.sent {
+		dst[key] = append([]string{}, values...)
+	}
+}
+
+// discardWriter is a http.ResponseWriter that discards the response.
+// It is used to refresh cached responses in the background.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) WriteHeader(status int) {}
+
+func (w *discardWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
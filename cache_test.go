@@ -0,0 +1,311 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", &CachedResponse{Status: 1})
+	cache.Set("b", &CachedResponse{Status: 2})
+	cache.Get("a")
+	cache.Set("c", &CachedResponse{Status: 3})
+
+	assert.Equal(t, 2, cache.Len())
+	_, ok := cache.Get("b")
+	assert.False(t, ok)
+
+	response, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, response.Status)
+
+	cache.Set("a", &CachedResponse{Status: 4})
+	response, _ = cache.Get("a")
+	assert.Equal(t, 4, response.Status)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestCacheMiddleware(t *testing.T) {
+	var calls int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "response %d", calls)
+	})
+
+	middleware := CacheMiddleware(NewMemoryCache(10), CacheConfig{DefaultTTL: time.Minute})(handler)
+
+	recorder := httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/products?page=1", nil))
+	assert.Equal(t, "MISS", recorder.Header().Get("X-Cache"))
+	assert.Equal(t, "response 1", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/products?page=1", nil))
+	assert.Equal(t, "HIT", recorder.Header().Get("X-Cache"))
+	assert.Equal(t, "text/plain", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "0", recorder.Header().Get("Age"))
+	assert.Equal(t, "response 1", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/products?page=2", nil))
+	assert.Equal(t, "MISS", recorder.Header().Get("X-Cache"))
+
+	r := httptest.NewRequest("GET", "/products?page=1", nil)
+	r.Header.Set("Cache-Control", "no-cache")
+	recorder = httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, r)
+	assert.Equal(t, "MISS", recorder.Header().Get("X-Cache"))
+	assert.Equal(t, "response 3", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, httptest.NewRequest("POST", "/products?page=1", nil))
+	assert.Equal(t, "", recorder.Header().Get("X-Cache"))
+	assert.Equal(t, 4, calls)
+}
+
+func TestCacheMiddlewareCacheControl(t *testing.T) {
+	cases := map[string]struct {
+		CacheControl string
+		Vary         string
+		Cached       bool
+	}{
+		"Default TTL":           {Cached: true},
+		"max-age":               {CacheControl: "public, max-age=60", Cached: true},
+		"max-age zero":          {CacheControl: "max-age=0"},
+		"no-store":              {CacheControl: "no-store"},
+		"private":               {CacheControl: "private, max-age=60"},
+		"no-cache":              {CacheControl: "no-cache"},
+		"must-revalidate stale": {CacheControl: "must-revalidate, max-age=0, stale-while-revalidate=60"},
+		"must-revalidate fresh": {CacheControl: "must-revalidate, max-age=60", Cached: true},
+		"Vary star":             {Vary: "*"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if c.CacheControl != "" {
+					w.Header().Set("Cache-Control", c.CacheControl)
+				}
+
+				if c.Vary != "" {
+					w.Header().Set("Vary", c.Vary)
+				}
+			})
+
+			middleware := CacheMiddleware(NewMemoryCache(10), CacheConfig{DefaultTTL: time.Minute})(handler)
+			middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+			recorder := httptest.NewRecorder()
+			middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, c.Cached, recorder.Header().Get("X-Cache") == "HIT")
+		})
+	}
+}
+
+func TestCacheMiddlewareVary(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte(r.Header.Get("Accept-Language")))
+	})
+
+	middleware := CacheMiddleware(NewMemoryCache(10), CacheConfig{DefaultTTL: time.Minute})(handler)
+	request := func(language string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Language", language)
+
+		recorder := httptest.NewRecorder()
+		middleware.ServeHTTP(recorder, r)
+		return recorder
+	}
+
+	assert.Equal(t, "MISS", request("en").Header().Get("X-Cache"))
+	assert.Equal(t, "MISS", request("fr").Header().Get("X-Cache"))
+
+	recorder := request("en")
+	assert.Equal(t, "HIT", recorder.Header().Get("X-Cache"))
+	assert.Equal(t, "en", recorder.Body.String())
+
+	recorder = request("fr")
+	assert.Equal(t, "HIT", recorder.Header().Get("X-Cache"))
+	assert.Equal(t, "fr", recorder.Body.String())
+}
+
+func TestCacheMiddlewareStaleWhileRevalidate(t *testing.T) {
+	var calls int32
+	revalidated := make(chan struct{}, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := MatchedRoute(r); !ok {
+			http.Error(w, "missing route", http.StatusInternalServerError)
+			return
+		}
+
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60, stale-while-revalidate=60")
+		fmt.Fprintf(w, "response %d", n)
+		if n > 1 {
+			revalidated <- struct{}{}
+		}
+	})
+
+	cache := NewMemoryCache(10)
+	cache.Set("GET example.com/", &CachedResponse{
+		Status:               http.StatusOK,
+		Header:               http.Header{},
+		Body:                 []byte("stale"),
+		Stored:               time.Now().Add(-90 * time.Second),
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Minute,
+	})
+
+	atomic.StoreInt32(&calls, 1)
+	middleware := CacheMiddleware(cache, CacheConfig{DefaultTTL: time.Minute})(handler)
+
+	r := withRoute(httptest.NewRequest("GET", "/", nil), Route{Method: "GET", Pattern: "/", Kind: "string"})
+	recorder := httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, r)
+	assert.Equal(t, "HIT", recorder.Header().Get("X-Cache"))
+	assert.Equal(t, "stale", recorder.Body.String())
+
+	select {
+	case <-revalidated:
+	case <-time.After(time.Second):
+		t.Fatal("response was not revalidated")
+	}
+
+	assert.Eventually(t, func() bool {
+		response, _ := cache.Get("GET example.com/")
+		return string(response.Body) == "response 2"
+	}, time.Second, time.Millisecond)
+}
+
+func TestCacheMiddlewarePrivateResponses(t *testing.T) {
+	cases := map[string]struct {
+		CacheControl string
+		SetCookie    bool
+		Cached       bool
+	}{
+		"Authorization":          {},
+		"Authorization public":   {CacheControl: "public, max-age=60", Cached: true},
+		"Authorization s-maxage": {CacheControl: "s-maxage=60", Cached: true},
+		"Set-Cookie":             {CacheControl: "public, max-age=60", SetCookie: true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				username, _, _ := r.BasicAuth()
+				if c.CacheControl != "" {
+					w.Header().Set("Cache-Control", c.CacheControl)
+				}
+
+				if c.SetCookie {
+					http.SetCookie(w, &http.Cookie{Name: "session", Value: username})
+				}
+
+				fmt.Fprintf(w, "hello %s", username)
+			})
+
+			middleware := CacheMiddleware(NewMemoryCache(10), CacheConfig{DefaultTTL: time.Minute})(handler)
+			request := func(username string) *httptest.ResponseRecorder {
+				r := httptest.NewRequest("GET", "/", nil)
+				r.SetBasicAuth(username, "password")
+
+				recorder := httptest.NewRecorder()
+				middleware.ServeHTTP(recorder, r)
+				return recorder
+			}
+
+			assert.Equal(t, "MISS", request("alice").Header().Get("X-Cache"))
+
+			recorder := request("bob")
+			if !c.Cached {
+				assert.Equal(t, "MISS", recorder.Header().Get("X-Cache"))
+				assert.Equal(t, "hello bob", recorder.Body.String())
+				return
+			}
+
+			assert.Equal(t, "HIT", recorder.Header().Get("X-Cache"))
+		})
+	}
+}
+
+func TestCacheMiddlewareHost(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	})
+
+	middleware := CacheMiddleware(NewMemoryCache(10), CacheConfig{DefaultTTL: time.Minute})(handler)
+	request := func(host string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Host = host
+
+		recorder := httptest.NewRecorder()
+		middleware.ServeHTTP(recorder, r)
+		return recorder
+	}
+
+	assert.Equal(t, "MISS", request("a.example.com").Header().Get("X-Cache"))
+
+	recorder := request("b.example.com")
+	assert.Equal(t, "MISS", recorder.Header().Get("X-Cache"))
+	assert.Equal(t, "b.example.com", recorder.Body.String())
+
+	recorder = request("a.example.com")
+	assert.Equal(t, "HIT", recorder.Header().Get("X-Cache"))
+	assert.Equal(t, "a.example.com", recorder.Body.String())
+}
+
+func TestCacheMiddlewareMaxBodySize(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	})
+
+	middleware := CacheMiddleware(NewMemoryCache(10), CacheConfig{DefaultTTL: time.Minute, MaxBodySize: 4})(handler)
+	for path, cached := range map[string]bool{"/ab": true, "/abcd": false} {
+		middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+
+		recorder := httptest.NewRecorder()
+		middleware.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, path, recorder.Body.String())
+		assert.Equal(t, cached, recorder.Header().Get("X-Cache") == "HIT", path)
+	}
+}
+
+func TestCacheMiddlewareOuterHeaders(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("body"))
+	})
+
+	var requests int
+	outer := func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("X-Request", fmt.Sprint(requests))
+			handler.ServeHTTP(w, r)
+		})
+	}
+
+	cache := NewMemoryCache(10)
+	middleware := outer(CacheMiddleware(cache, CacheConfig{DefaultTTL: time.Minute})(handler))
+	for i := 1; i <= 3; i++ {
+		recorder := httptest.NewRecorder()
+		middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, fmt.Sprint(i), recorder.Header().Get("X-Request"))
+		assert.Equal(t, "text/plain", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "body", recorder.Body.String())
+	}
+
+	cached, ok := cache.Get("GET example.com/")
+	if assert.True(t, ok) {
+		assert.Equal(t, http.Header{"Content-Type": {"text/plain"}}, cached.Header)
+	}
+}
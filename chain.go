@@ -0,0 +1,76 @@
+package router
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// A Chain composes Middleware from left to right:
+// the first Middleware in a Chain is the outermost and runs first.
+// This is the opposite of RouteMap.ApplyMiddleware, where the first Middleware is the innermost.
+// A Chain is immutable, so it can safely be reused across RouteMaps and Routers.
+type Chain []Middleware
+
+// NewChain returns a Chain of the specified middleware.
+func NewChain(middleware ...Middleware) Chain {
+	return Chain(nil).Append(middleware...)
+}
+
+// Append returns a new Chain with middleware added to the end of c.
+func (c Chain) Append(middleware ...Middleware) Chain {
+	chain := make(Chain, 0, len(c)+len(middleware))
+	chain = append(chain, c...)
+	return append(chain, middleware...)
+}
+
+// Extend returns a new Chain with the middleware of other added to the end of c.
+func (c Chain) Extend(other Chain) Chain {
+	return c.Append(other...)
+}
+
+// Then returns handler wrapped by each Middleware in c.
+func (c Chain) Then(handler http.Handler) http.Handler {
+	for i := len(c) - 1; i >= 0; i-- {
+		handler = c[i](handler)
+	}
+
+	return handler
+}
+
+// ThenFunc returns fn wrapped by each Middleware in c.
+func (c Chain) ThenFunc(fn http.HandlerFunc) http.Handler {
+	return c.Then(fn)
+}
+
+// Middleware returns c as a single Middleware, which can be used with RouteMap.ApplyMiddleware.
+func (c Chain) Middleware() Middleware {
+	return c.Then
+}
+
+// chainCache holds a handler wrapped by a Chain, so that the Chain is only applied again
+// when it is replaced by a different Chain.
+// Since Chains are immutable, Chains with the same length and backing array are the same.
+type chainCache struct {
+	v atomic.Value
+}
+
+type cachedChain struct {
+	chain   Chain
+	wrapped http.Handler
+}
+
+// then returns handler wrapped by chain, reusing the previously wrapped handler if chain has not changed.
+// handler must be the same on every call.
+func (cc *chainCache) then(chain Chain, handler http.Handler) http.Handler {
+	if cached, ok := cc.v.Load().(*cachedChain); ok && sameChain(cached.chain, chain) {
+		return cached.wrapped
+	}
+
+	wrapped := chain.Then(handler)
+	cc.v.Store(&cachedChain{chain: chain, wrapped: wrapped})
+	return wrapped
+}
+
+func sameChain(a, b Chain) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
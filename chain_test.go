@@ -0,0 +1,74 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func recordingMiddleware(order *[]string, name string) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*order = append(*order, name+" before")
+			handler.ServeHTTP(w, r)
+			*order = append(*order, name+" after")
+		})
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	chain := NewChain(recordingMiddleware(&order, "a"), recordingMiddleware(&order, "b"))
+	handler := chain.ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), NewRequest("GET", "/"))
+	assert.Equal(t, []string{"a before", "b before", "handler", "b after", "a after"}, order)
+}
+
+func TestChainAppendExtend(t *testing.T) {
+	var order []string
+	base := NewChain(recordingMiddleware(&order, "a"))
+	appended := base.Append(recordingMiddleware(&order, "b"))
+	extended := base.Extend(NewChain(recordingMiddleware(&order, "c"), recordingMiddleware(&order, "d")))
+
+	assert.Len(t, base, 1)
+	assert.Len(t, appended, 2)
+	assert.Len(t, extended, 3)
+
+	extended.ThenFunc(func(w http.ResponseWriter, r *http.Request) {}).ServeHTTP(nil, nil)
+	assert.Equal(t, []string{"a before", "c before", "d before", "d after", "c after", "a after"}, order)
+
+	order = nil
+	appended.ThenFunc(func(w http.ResponseWriter, r *http.Request) {}).ServeHTTP(nil, nil)
+	assert.Equal(t, []string{"a before", "b before", "b after", "a after"}, order)
+}
+
+func TestChainMiddleware(t *testing.T) {
+	var order []string
+	chain := NewChain(recordingMiddleware(&order, "a"), recordingMiddleware(&order, "b"))
+
+	rm := RouteMap{
+		"/products": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, "handler")
+			}),
+		},
+	}
+
+	rm.ApplyMiddleware(chain.Middleware())
+	rm["/products"][http.MethodGet].ServeHTTP(nil, nil)
+	assert.Equal(t, []string{"a before", "b before", "handler", "b after", "a after"}, order)
+}
+
+func TestEmptyChain(t *testing.T) {
+	var called bool
+	Chain{}.ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}).ServeHTTP(nil, nil)
+
+	assert.True(t, called)
+}
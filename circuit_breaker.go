@@ -0,0 +1,246 @@
+package router
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through and counts failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests fast without executing the handler.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through
+	// to decide whether to close or reopen the circuit.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// Window is the period over which failures are counted. Defaults to 10 seconds.
+	Window time.Duration
+	// MinRequests is the number of requests required in a window before the circuit can open.
+	// Defaults to 20.
+	MinRequests int
+	// FailureRatio is the ratio of failed requests in a window that opens the circuit. Defaults to 0.5.
+	FailureRatio float64
+	// SlowThreshold, if set, counts requests that take longer than it as failures.
+	SlowThreshold time.Duration
+	// OpenDuration is how long the circuit stays open before allowing probes. Defaults to 30 seconds.
+	OpenDuration time.Duration
+	// HalfOpenRequests is the number of successful probes required to close the circuit. Defaults to 1.
+	HalfOpenRequests int
+	// Fallback handles requests while the circuit is open.
+	// Defaults to a 503 Service Unavailable response.
+	Fallback http.Handler
+}
+
+// A CircuitBreaker tracks failures for each route, and stops executing a route's handler
+// while it is failing.
+// Requests fail when their response status is 5xx or they are slower than the config's SlowThreshold.
+type CircuitBreaker struct {
+	config CircuitBreakerConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	successes   int
+}
+
+// NewCircuitBreaker returns a CircuitBreaker with the specified config.
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.Window <= 0 {
+		config.Window = 10 * time.Second
+	}
+
+	if config.MinRequests <= 0 {
+		config.MinRequests = 20
+	}
+
+	if config.FailureRatio <= 0 {
+		config.FailureRatio = 0.5
+	}
+
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = 30 * time.Second
+	}
+
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+
+	if config.Fallback == nil {
+		config.Fallback = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "503 Service Unavailable", http.StatusServiceUnavailable)
+		})
+	}
+
+	return &CircuitBreaker{
+		config:   config,
+		now:      time.Now,
+		circuits: map[string]*circuit{},
+	}
+}
+
+// CircuitBreakerMiddleware returns a Middleware that fails fast with cb's fallback handler
+// while the circuit for the request's route is open.
+// Circuits are keyed by the matched route's method and pattern, such as "GET /products/:productID",
+// so CircuitBreakerMiddleware should be applied using RouteMap.ApplyMiddleware or Router.MatchMiddleware.
+func CircuitBreakerMiddleware(cb *CircuitBreaker) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Method
+			if route, ok := MatchedRoute(r); ok {
+				key = route.Method + " " + route.Pattern
+			}
+
+			if !cb.allow(key) {
+				cb.config.Fallback.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			sw := newStatusWriter(w)
+			success := false
+			defer func() {
+				cb.record(key, success)
+			}()
+
+			handler.ServeHTTP(sw, r)
+			slow := cb.config.SlowThreshold > 0 && time.Since(start) > cb.config.SlowThreshold
+			success = sw.Status() < 500 && !slow
+		})
+	}
+}
+
+// State returns the state of the circuit for key, such as "GET /products/:productID".
+func (cb *CircuitBreaker) State(key string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[key]
+	if !ok {
+		return CircuitClosed
+	}
+
+	cb.update(c)
+	return c.state
+}
+
+// States returns the state of every circuit in cb, keyed by route.
+func (cb *CircuitBreaker) States() map[string]CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	states := make(map[string]CircuitState, len(cb.circuits))
+	for key, c := range cb.circuits {
+		cb.update(c)
+		states[key] = c.state
+	}
+
+	return states
+}
+
+// allow reports whether a request for key should be executed.
+func (cb *CircuitBreaker) allow(key string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[key]
+	if !ok {
+		c = &circuit{windowStart: cb.now()}
+		cb.circuits[key] = c
+	}
+
+	cb.update(c)
+	switch c.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if c.probes >= cb.config.HalfOpenRequests {
+			return false
+		}
+
+		c.probes++
+	}
+
+	return true
+}
+
+// record counts the result of a request for key.
+func (cb *CircuitBreaker) record(key string, success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c := cb.circuits[key]
+	now := cb.now()
+	switch c.state {
+	case CircuitHalfOpen:
+		if !success {
+			c.state = CircuitOpen
+			c.openedAt = now
+			return
+		}
+
+		c.successes++
+		if c.successes >= cb.config.HalfOpenRequests {
+			*c = circuit{windowStart: now}
+		}
+	case CircuitClosed:
+		c.requests++
+		if !success {
+			c.failures++
+		}
+
+		if c.requests >= cb.config.MinRequests && float64(c.failures)/float64(c.requests) >= cb.config.FailureRatio {
+			c.state = CircuitOpen
+			c.openedAt = now
+		}
+	}
+}
+
+// update moves c to its next state based on the time.
+// It must be called with cb.mu held.
+func (cb *CircuitBreaker) update(c *circuit) {
+	now := cb.now()
+	switch c.state {
+	case CircuitClosed:
+		if now.Sub(c.windowStart) >= cb.config.Window {
+			c.windowStart = now
+			c.requests = 0
+			c.failures = 0
+		}
+	case CircuitOpen:
+		if now.Sub(c.openedAt) >= cb.config.OpenDuration {
+			c.state = CircuitHalfOpen
+			c.probes = 0
+			c.successes = 0
+		}
+	}
+}
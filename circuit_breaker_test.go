@@ -0,0 +1,114 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerMiddleware(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		MinRequests:      4,
+		FailureRatio:     0.5,
+		OpenDuration:     time.Minute,
+		HalfOpenRequests: 2,
+		Fallback: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}),
+	})
+	cb.now = func() time.Time { return now }
+
+	status := http.StatusInternalServerError
+	rm := RouteMap{
+		"/products/:productID": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}),
+		},
+		"/health": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		},
+	}
+
+	rm.ApplyMiddleware(CircuitBreakerMiddleware(cb))
+	router := NewRouter(rm.VariableMatch())
+	serve := func(path string) int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		return recorder.Code
+	}
+
+	key := "GET /products/:productID"
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusInternalServerError, serve("/products/p1"))
+		assert.Equal(t, CircuitClosed, cb.State(key))
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, serve("/products/p2"))
+	assert.Equal(t, CircuitOpen, cb.State(key))
+	assert.Equal(t, http.StatusTeapot, serve("/products/p1"))
+	assert.Equal(t, http.StatusOK, serve("/health"))
+	assert.Equal(t, map[string]CircuitState{key: CircuitOpen, "GET /health": CircuitClosed}, cb.States())
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, CircuitHalfOpen, cb.State(key))
+	assert.Equal(t, http.StatusInternalServerError, serve("/products/p1"))
+	assert.Equal(t, CircuitOpen, cb.State(key))
+
+	now = now.Add(time.Minute)
+	status = http.StatusOK
+	assert.Equal(t, http.StatusOK, serve("/products/p1"))
+	assert.Equal(t, CircuitHalfOpen, cb.State(key))
+	assert.Equal(t, http.StatusOK, serve("/products/p1"))
+	assert.Equal(t, CircuitClosed, cb.State(key))
+}
+
+func TestCircuitBreakerHalfOpenProbes(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(CircuitBreakerConfig{MinRequests: 1, OpenDuration: time.Second})
+	cb.now = func() time.Time { return now }
+
+	assert.True(t, cb.allow("key"))
+	cb.record("key", false)
+	assert.False(t, cb.allow("key"))
+
+	now = now.Add(time.Second)
+	assert.True(t, cb.allow("key"))
+	assert.False(t, cb.allow("key"))
+
+	cb.record("key", true)
+	assert.Equal(t, CircuitClosed, cb.State("key"))
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(CircuitBreakerConfig{MinRequests: 2, Window: time.Second})
+	cb.now = func() time.Time { return now }
+
+	cb.allow("key")
+	cb.record("key", false)
+
+	now = now.Add(time.Second)
+	cb.allow("key")
+	cb.record("key", false)
+	assert.Equal(t, CircuitClosed, cb.State("key"))
+}
+
+func TestCircuitBreakerSlowRequests(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{MinRequests: 1, SlowThreshold: time.Nanosecond})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+	})
+
+	CircuitBreakerMiddleware(cb)(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, CircuitOpen, cb.State("GET"))
+
+	recorder := httptest.NewRecorder()
+	CircuitBreakerMiddleware(cb)(handler).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "open", cb.State("GET").String())
+}
@@ -0,0 +1,175 @@
+package router
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ConcurrencyConfig configures a ConcurrencyLimiter.
+type ConcurrencyConfig struct {
+	// Limit is the maximum number of requests served at once.
+	Limit int
+	// QueueSize is the maximum number of requests waiting to be served.
+	// Requests that arrive when the queue is full are shed immediately.
+	QueueSize int
+	// MaxWait is how long a queued request waits before it is shed.
+	MaxWait time.Duration
+	// RetryAfter is the value of the Retry-After header in responses to shed requests.
+	// Defaults to one second.
+	RetryAfter time.Duration
+	// TargetLatency enables adaptive limiting when set.
+	// Each request that takes longer than TargetLatency halves the current limit,
+	// and each request that takes less increases it by one, up to Limit.
+	TargetLatency time.Duration
+	// MinLimit is the lowest the current limit is reduced to by adaptive limiting. Defaults to 1.
+	MinLimit int
+}
+
+// A ConcurrencyLimiter caps the number of requests being served at once.
+// It is safe for concurrent use.
+type ConcurrencyLimiter struct {
+	config ConcurrencyConfig
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	waiters  *list.List
+}
+
+type concurrencyWaiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// NewConcurrencyLimiter returns a ConcurrencyLimiter with the specified config.
+func NewConcurrencyLimiter(config ConcurrencyConfig) *ConcurrencyLimiter {
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = time.Second
+	}
+
+	if config.MinLimit <= 0 {
+		config.MinLimit = 1
+	}
+
+	return &ConcurrencyLimiter{
+		config:  config,
+		limit:   float64(config.Limit),
+		waiters: list.New(),
+	}
+}
+
+// Limit returns the current limit of l, which changes over time if adaptive limiting is enabled.
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.limit)
+}
+
+// InFlight returns the number of requests being served.
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.inFlight
+}
+
+// ConcurrencyLimitMiddleware returns a Middleware that only serves requests when limiter has capacity.
+// Otherwise, requests wait in the limiter's queue, and are shed with a 503 Service Unavailable
+// response and a Retry-After header if the queue is full or they wait longer than the limiter's MaxWait.
+// Every handler the Middleware is applied to shares limiter, so a single limiter can be applied
+// as Router.Middleware to cap requests globally, and separate limiters can be applied
+// to separate routes using RouteMap.ApplyMiddlewareIf or MethodHandlers.ApplyMiddleware.
+func ConcurrencyLimitMiddleware(limiter *ConcurrencyLimiter) Middleware {
+	retryAfter := strconv.Itoa(seconds(limiter.config.RetryAfter))
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limiter.acquire(r.Context()) {
+				w.Header().Set("Retry-After", retryAfter)
+				http.Error(w, "503 Service Unavailable", http.StatusServiceUnavailable)
+				return
+			}
+
+			start := time.Now()
+			defer func() {
+				limiter.release(time.Since(start))
+			}()
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acquire reserves capacity for a request, waiting in the queue if necessary.
+// It returns false if the request should be shed.
+func (l *ConcurrencyLimiter) acquire(ctx context.Context) bool {
+	l.mu.Lock()
+	if l.inFlight < int(l.limit) && l.waiters.Len() == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+		return true
+	}
+
+	if l.waiters.Len() >= l.config.QueueSize {
+		l.mu.Unlock()
+		return false
+	}
+
+	waiter := &concurrencyWaiter{ready: make(chan struct{})}
+	element := l.waiters.PushBack(waiter)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.config.MaxWait)
+	defer timer.Stop()
+
+	select {
+	case <-waiter.ready:
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if waiter.granted {
+		// capacity was granted while timing out
+		return true
+	}
+
+	l.waiters.Remove(element)
+	return false
+}
+
+// release frees the capacity reserved for a request that took latency to serve.
+func (l *ConcurrencyLimiter) release(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if l.config.TargetLatency > 0 {
+		if latency > l.config.TargetLatency {
+			l.limit = math.Max(float64(l.config.MinLimit), l.limit/2)
+		} else {
+			l.limit = math.Min(float64(l.config.Limit), l.limit+1)
+		}
+	}
+
+	l.grant()
+}
+
+// grant hands capacity to queued requests.
+// It must be called with l.mu held.
+func (l *ConcurrencyLimiter) grant() {
+	for l.inFlight < int(l.limit) && l.waiters.Len() > 0 {
+		waiter := l.waiters.Remove(l.waiters.Front()).(*concurrencyWaiter)
+		waiter.granted = true
+		l.inFlight++
+		close(waiter.ready)
+	}
+}
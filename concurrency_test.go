@@ -0,0 +1,115 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyConfig{
+		Limit:      1,
+		QueueSize:  1,
+		MaxWait:    time.Second,
+		RetryAfter: 2 * time.Second,
+	})
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-unblock
+		}
+	})
+
+	middleware := ConcurrencyLimitMiddleware(limiter)(handler)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		recorder := httptest.NewRecorder()
+		middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/slow", nil))
+		codes[0] = recorder.Code
+	}()
+
+	<-started
+	assert.Equal(t, 1, limiter.InFlight())
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		recorder := httptest.NewRecorder()
+		middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/queued", nil))
+		codes[1] = recorder.Code
+	}()
+
+	assert.Eventually(t, func() bool {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		return limiter.waiters.Len() == 1
+	}, time.Second, time.Millisecond)
+
+	recorder := httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/shed", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "2", recorder.Header().Get("Retry-After"))
+
+	close(unblock)
+	wg.Wait()
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK}, codes)
+	assert.Equal(t, 0, limiter.InFlight())
+}
+
+func TestConcurrencyLimiterMaxWait(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyConfig{
+		Limit:     1,
+		QueueSize: 1,
+		MaxWait:   time.Millisecond,
+	})
+
+	assert.True(t, limiter.acquire(context.Background()))
+	assert.False(t, limiter.acquire(context.Background()))
+	assert.Equal(t, 0, limiter.waiters.Len())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter.config.MaxWait = time.Hour
+	assert.False(t, limiter.acquire(ctx))
+
+	limiter.release(0)
+	assert.True(t, limiter.acquire(context.Background()))
+}
+
+func TestConcurrencyLimiterAdaptive(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyConfig{
+		Limit:         8,
+		TargetLatency: 100 * time.Millisecond,
+		MinLimit:      2,
+	})
+
+	for i := 0; i < 3; i++ {
+		limiter.acquire(context.Background())
+		limiter.release(time.Second)
+	}
+
+	assert.Equal(t, 2, limiter.Limit())
+
+	limiter.acquire(context.Background())
+	limiter.release(time.Millisecond)
+	assert.Equal(t, 3, limiter.Limit())
+
+	for i := 0; i < 10; i++ {
+		limiter.acquire(context.Background())
+		limiter.release(time.Millisecond)
+	}
+
+	assert.Equal(t, 8, limiter.Limit())
+}
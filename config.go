@@ -0,0 +1,257 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// RouteConfig declares routes in a JSON or YAML configuration file.
+// For example:
+//   matcher: variable
+//   routes:
+//     - pattern: /products/:productID
+//       method: GET
+//       handler: getProduct
+//       middleware:
+//         - name: rateLimit
+//           options:
+//             limit: 100
+type RouteConfig struct {
+	// Matcher is the default matcher type for routes: "glob", "regex", "string" or "variable".
+	// Defaults to "variable".
+	Matcher string            `json:"matcher" yaml:"matcher"`
+	Routes  []RouteDefinition `json:"routes" yaml:"routes"`
+}
+
+// A RouteDefinition declares a single route in a RouteConfig.
+type RouteDefinition struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Method  string `json:"method" yaml:"method"`
+	// Handler is the name of the route's handler in the Registry.
+	Handler string `json:"handler" yaml:"handler"`
+	// Matcher overrides the RouteConfig's Matcher for this route.
+	Matcher string `json:"matcher,omitempty" yaml:"matcher,omitempty"`
+	// Middleware is applied in order, so the first middleware is the outermost and runs first.
+	Middleware []MiddlewareDefinition `json:"middleware,omitempty" yaml:"middleware,omitempty"`
+}
+
+// A MiddlewareDefinition declares a middleware, and its options, in a RouteDefinition.
+type MiddlewareDefinition struct {
+	// Name is the name of the middleware's factory in the Registry.
+	Name    string                 `json:"name" yaml:"name"`
+	Options map[string]interface{} `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// A MiddlewareFactory creates a Middleware from the options in a MiddlewareDefinition.
+type MiddlewareFactory func(options map[string]interface{}) (Middleware, error)
+
+// A Registry holds the handlers and middleware that a RouteConfig can refer to by name.
+type Registry struct {
+	Handlers   map[string]http.Handler
+	Middleware map[string]MiddlewareFactory
+}
+
+// LoadRouteConfig reads a RouteConfig from the file at path.
+// Files with a .json extension are parsed as JSON, and files with a .yaml or .yml extension as YAML.
+func LoadRouteConfig(path string) (*RouteConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var format string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = "json"
+	case ".yaml", ".yml":
+		format = "yaml"
+	default:
+		return nil, fmt.Errorf("%s: unsupported file extension '%s'", path, filepath.Ext(path))
+	}
+
+	config, err := ParseRouteConfig(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// ParseRouteConfig parses a RouteConfig from data in the specified format, "json" or "yaml".
+// Unknown fields, empty documents, and content after the first JSON value or YAML document are reported as errors.
+func ParseRouteConfig(data []byte, format string) (*RouteConfig, error) {
+	var config RouteConfig
+	switch format {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return nil, configDecodeError(err)
+		}
+
+		if decoder.More() {
+			return nil, errors.New("unexpected content after the first JSON value")
+		}
+	case "yaml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil {
+			return nil, configDecodeError(err)
+		}
+
+		var extra interface{}
+		if err := decoder.Decode(&extra); err != io.EOF {
+			return nil, errors.New("unexpected content after the first YAML document")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format '%s'", format)
+	}
+
+	return &config, nil
+}
+
+// configDecodeError reports an empty document instead of a bare io.EOF.
+func configDecodeError(err error) error {
+	if err == io.EOF {
+		return errors.New("empty document")
+	}
+
+	return err
+}
+
+// RouteMap resolves the routes in c against registry and returns them as a RouteMap.
+// Since a RouteMap has a single matcher type, chosen when it is matched,
+// routes must not override c.Matcher; use Router for configs with mixed matcher types.
+// The RouteMap should be matched using c.Matcher, such as rm.VariableMatch() for "variable".
+// Errors identify the offending route, such as "routes[2]: unknown handler 'getProduct'".
+func (c *RouteConfig) RouteMap(registry Registry) (RouteMap, error) {
+	for i, d := range c.Routes {
+		if d.Matcher != "" && d.Matcher != c.Matcher && !(c.Matcher == "" && d.Matcher == "variable") {
+			return nil, fmt.Errorf("routes[%d]: matcher '%s' differs from the config's matcher; use Router for mixed matchers", i, d.Matcher)
+		}
+	}
+
+	rm := RouteMap{}
+	err := c.resolve(registry, func(d RouteDefinition, kind string, handler http.Handler) {
+		if _, ok := rm[d.Pattern]; !ok {
+			rm[d.Pattern] = MethodHandlers{}
+		}
+
+		rm[d.Pattern][d.Method] = handler
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rm, nil
+}
+
+// Router resolves the routes in c against registry and returns a Router that matches them
+// in the order they are defined, using each route's matcher type.
+func (c *RouteConfig) Router(registry Registry) (*Router, error) {
+	matchers := []HandlerMatcher{}
+	err := c.resolve(registry, func(d RouteDefinition, kind string, handler http.Handler) {
+		switch kind {
+		case "glob":
+			matchers = append(matchers, NewGlobHandlerMatcher(d.Method, d.Pattern, handler))
+		case "regex":
+			matchers = append(matchers, NewRegexHandlerMatcher(d.Method, d.Pattern, handler))
+		case "string":
+			matchers = append(matchers, NewStringHandlerMatcher(d.Method, d.Pattern, handler))
+		case "variable":
+			matchers = append(matchers, NewVariableHandlerMatcher(d.Method, d.Pattern, handler))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRouter(matchers), nil
+}
+
+// resolve validates each route in c, resolves its handler and middleware against registry,
+// and calls add with the route, its matcher type, and its wrapped handler.
+func (c *RouteConfig) resolve(registry Registry, add func(d RouteDefinition, kind string, handler http.Handler)) error {
+	seen := map[string]int{}
+	for i, d := range c.Routes {
+		kind := d.Matcher
+		if kind == "" {
+			kind = c.Matcher
+		}
+
+		if kind == "" {
+			kind = "variable"
+		}
+
+		d.Method = strings.ToUpper(d.Method)
+		if err := validateRoute(d, kind); err != nil {
+			return fmt.Errorf("routes[%d]: %v", i, err)
+		}
+
+		key := d.Method + " " + d.Pattern
+		if j, ok := seen[key]; ok {
+			return fmt.Errorf("routes[%d]: duplicate route '%s', first defined in routes[%d]", i, key, j)
+		}
+
+		seen[key] = i
+
+		handler, ok := registry.Handlers[d.Handler]
+		if !ok {
+			return fmt.Errorf("routes[%d]: unknown handler '%s'", i, d.Handler)
+		}
+
+		middleware := make([]Middleware, 0, len(d.Middleware))
+		for j, md := range d.Middleware {
+			factory, ok := registry.Middleware[md.Name]
+			if !ok {
+				return fmt.Errorf("routes[%d].middleware[%d]: unknown middleware '%s'", i, j, md.Name)
+			}
+
+			m, err := factory(md.Options)
+			if err != nil {
+				return fmt.Errorf("routes[%d].middleware[%d]: %s: %v", i, j, md.Name, err)
+			}
+
+			middleware = append(middleware, m)
+		}
+
+		add(d, kind, NewChain(middleware...).Then(handler))
+	}
+
+	return nil
+}
+
+func validateRoute(d RouteDefinition, kind string) error {
+	if d.Method == "" {
+		return fmt.Errorf("missing method")
+	}
+
+	if d.Pattern == "" {
+		return fmt.Errorf("missing pattern")
+	}
+
+	switch kind {
+	case "regex":
+		if _, err := regexp.Compile(d.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %v", d.Pattern, err)
+		}
+	case "glob", "string", "variable":
+		if !strings.HasPrefix(d.Pattern, "/") {
+			return fmt.Errorf("invalid pattern '%s': must begin with '/'", d.Pattern)
+		}
+	default:
+		return fmt.Errorf("unknown matcher '%s'", kind)
+	}
+
+	return nil
+}
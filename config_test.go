@@ -0,0 +1,274 @@
+package router
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRegistry() Registry {
+	return Registry{
+		Handlers: map[string]http.Handler{
+			"ok": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			}),
+		},
+		Middleware: map[string]MiddlewareFactory{
+			"header": func(options map[string]interface{}) (Middleware, error) {
+				name, ok := options["name"].(string)
+				if !ok {
+					return nil, fmt.Errorf("missing option 'name'")
+				}
+
+				return func(handler http.Handler) http.Handler {
+					return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.Header().Add("X-Middleware", name)
+						handler.ServeHTTP(w, r)
+					})
+				}, nil
+			},
+		},
+	}
+}
+
+func TestLoadRouteConfig(t *testing.T) {
+	files := map[string]string{
+		"routes.json": `{
+			"matcher": "variable",
+			"routes": [
+				{"pattern": "/products/:productID", "method": "get", "handler": "ok",
+				 "middleware": [{"name": "header", "options": {"name": "a"}}, {"name": "header", "options": {"name": "b"}}]},
+				{"pattern": "^/files/.+$", "method": "GET", "handler": "ok", "matcher": "regex"}
+			]
+		}`,
+		"routes.yaml": `
+matcher: variable
+routes:
+  - pattern: /products/:productID
+    method: get
+    handler: ok
+    middleware:
+      - name: header
+        options:
+          name: a
+      - name: header
+        options:
+          name: b
+  - pattern: ^/files/.+$
+    method: GET
+    handler: ok
+    matcher: regex
+`,
+	}
+
+	dir, err := ioutil.TempDir("", "router")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadRouteConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := config.Router(newTestRegistry())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, NewRequest("GET", "/products/1"))
+			assert.Equal(t, "ok", recorder.Body.String())
+			assert.Equal(t, []string{"a", "b"}, recorder.Header()["X-Middleware"])
+
+			recorder = httptest.NewRecorder()
+			r.ServeHTTP(recorder, NewRequest("GET", "/files/a/b.txt"))
+			assert.Equal(t, http.StatusOK, recorder.Code)
+
+			// the routes mix matcher types, so they cannot be represented by a RouteMap
+			_, err = config.RouteMap(newTestRegistry())
+			assert.Error(t, err)
+
+			config.Routes = config.Routes[:1]
+			rm, err := config.RouteMap(newTestRegistry())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Len(t, rm, 1)
+			assert.Contains(t, rm["/products/:productID"], http.MethodGet)
+		})
+	}
+}
+
+func TestRouteConfigErrors(t *testing.T) {
+	cases := map[string]struct {
+		Route    RouteDefinition
+		Expected string
+	}{
+		"Unknown handler": {
+			Route:    RouteDefinition{Pattern: "/products", Method: "GET", Handler: "missing"},
+			Expected: "routes[1]: unknown handler 'missing'",
+		},
+		"Unknown middleware": {
+			Route: RouteDefinition{Pattern: "/products", Method: "GET", Handler: "ok",
+				Middleware: []MiddlewareDefinition{{Name: "missing"}}},
+			Expected: "routes[1].middleware[0]: unknown middleware 'missing'",
+		},
+		"Invalid middleware options": {
+			Route: RouteDefinition{Pattern: "/products", Method: "GET", Handler: "ok",
+				Middleware: []MiddlewareDefinition{{Name: "header"}}},
+			Expected: "routes[1].middleware[0]: header: missing option 'name'",
+		},
+		"Invalid regex": {
+			Route:    RouteDefinition{Pattern: "/products/(", Method: "GET", Handler: "ok", Matcher: "regex"},
+			Expected: "routes[1]: invalid pattern '/products/(': error parsing regexp: missing closing ): `/products/(`",
+		},
+		"Invalid pattern": {
+			Route:    RouteDefinition{Pattern: "products", Method: "GET", Handler: "ok"},
+			Expected: "routes[1]: invalid pattern 'products': must begin with '/'",
+		},
+		"Unknown matcher": {
+			Route:    RouteDefinition{Pattern: "/products", Method: "GET", Handler: "ok", Matcher: "fuzzy"},
+			Expected: "routes[1]: unknown matcher 'fuzzy'",
+		},
+		"Missing method": {
+			Route:    RouteDefinition{Pattern: "/products", Handler: "ok"},
+			Expected: "routes[1]: missing method",
+		},
+		"Duplicate route": {
+			Route:    RouteDefinition{Pattern: "/", Method: "get", Handler: "ok"},
+			Expected: "routes[1]: duplicate route 'GET /', first defined in routes[0]",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			config := &RouteConfig{
+				Routes: []RouteDefinition{
+					{Pattern: "/", Method: "GET", Handler: "ok"},
+					c.Route,
+				},
+			}
+
+			_, err := config.Router(newTestRegistry())
+			if assert.Error(t, err) {
+				assert.Equal(t, c.Expected, err.Error())
+			}
+
+			_, err = config.RouteMap(newTestRegistry())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseRouteConfigUnknownField(t *testing.T) {
+	_, err := ParseRouteConfig([]byte(`{"routes": [{"path": "/"}]}`), "json")
+	assert.Error(t, err)
+
+	_, err = ParseRouteConfig([]byte("routes:\n  - path: /\n"), "yaml")
+	assert.Error(t, err)
+
+	_, err = ParseRouteConfig([]byte(`{}`), "toml")
+	assert.Error(t, err)
+}
+
+func TestParseRouteConfigTrailingContent(t *testing.T) {
+	cases := map[string]struct {
+		Data     string
+		Format   string
+		Expected string
+	}{
+		"JSON": {
+			Data:     `{"routes":[]}{"routes":[{"pattern":"/","method":"GET","handler":"ok"}]}`,
+			Format:   "json",
+			Expected: "unexpected content after the first JSON value",
+		},
+		"YAML": {
+			Data:     "routes: []\n---\nroutes:\n  - pattern: /\n",
+			Format:   "yaml",
+			Expected: "unexpected content after the first YAML document",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseRouteConfig([]byte(c.Data), c.Format)
+			if assert.Error(t, err) {
+				assert.Equal(t, c.Expected, err.Error())
+			}
+		})
+	}
+
+	_, err := ParseRouteConfig([]byte("{\"routes\":[]}\n\n"), "json")
+	assert.NoError(t, err)
+}
+
+func TestParseRouteConfigEmpty(t *testing.T) {
+	for _, format := range []string{"json", "yaml"} {
+		_, err := ParseRouteConfig([]byte(""), format)
+		if assert.Error(t, err, format) {
+			assert.Equal(t, "empty document", err.Error())
+		}
+	}
+}
+
+func TestRouteConfigRouteMapMatchers(t *testing.T) {
+	cases := map[string]struct {
+		Config   RouteConfig
+		Expected string
+	}{
+		"Default matcher": {
+			Config: RouteConfig{Routes: []RouteDefinition{
+				{Pattern: "/a", Method: "GET", Handler: "ok", Matcher: "variable"},
+			}},
+		},
+		"Config matcher": {
+			Config: RouteConfig{Matcher: "glob", Routes: []RouteDefinition{
+				{Pattern: "/a/*", Method: "GET", Handler: "ok"},
+				{Pattern: "/b/*", Method: "GET", Handler: "ok", Matcher: "glob"},
+			}},
+		},
+		"Mixed matchers": {
+			Config: RouteConfig{Routes: []RouteDefinition{
+				{Pattern: "/a", Method: "GET", Handler: "ok"},
+				{Pattern: "^/b$", Method: "GET", Handler: "ok", Matcher: "regex"},
+			}},
+			Expected: "routes[1]: matcher 'regex' differs from the config's matcher; use Router for mixed matchers",
+		},
+		"Overridden config matcher": {
+			Config: RouteConfig{Matcher: "glob", Routes: []RouteDefinition{
+				{Pattern: "/a", Method: "GET", Handler: "ok", Matcher: "string"},
+			}},
+			Expected: "routes[0]: matcher 'string' differs from the config's matcher; use Router for mixed matchers",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := c.Config.RouteMap(newTestRegistry())
+			if c.Expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			if assert.Error(t, err) {
+				assert.Equal(t, c.Expected, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package router
+
+// contextKey is the type of keys used to store values in a request's context.
+type contextKey int
+
+const (
+	basicAuthUsernameKey contextKey = iota
+	bearerClaimsKey
+	apiKeyScopesKey
+	matchedRouteKey
+	routeRecorderKey
+	spanContextKey
+	cspNonceKey
+	csrfTokenKey
+	csrfFailureReasonKey
+	clientIPKey
+)
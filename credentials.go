@@ -0,0 +1,144 @@
+package router
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// A CredentialProvider checks usernames and passwords.
+type CredentialProvider interface {
+	Authenticate(username, password string) bool
+}
+
+// CredentialProviderFunc is a function that implements CredentialProvider.
+type CredentialProviderFunc func(username, password string) bool
+
+// Authenticate calls fn(username, password).
+func (fn CredentialProviderFunc) Authenticate(username, password string) bool {
+	return fn(username, password)
+}
+
+// StaticCredentials is a CredentialProvider that maps usernames to plaintext passwords.
+type StaticCredentials map[string]string
+
+// Authenticate returns true if password matches username's password.
+// Passwords are compared in constant time.
+func (s StaticCredentials) Authenticate(username, password string) bool {
+	expected, ok := s[username]
+	if !ok {
+		// compare anyway so unknown usernames take as long as known ones
+		expected = password + "-"
+	}
+
+	a := sha256.Sum256([]byte(password))
+	b := sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1 && ok
+}
+
+// HtpasswdCredentials is a CredentialProvider that maps usernames to htpasswd hashes.
+// Bcrypt ("$2y$", "$2a$", "$2b$") and SHA1 ("{SHA}") hashes are supported.
+type HtpasswdCredentials struct {
+	hashes map[string]string
+	// dummy is compared against the passwords of unknown users,
+	// so that they take as long to reject as known users.
+	dummy string
+}
+
+// NewHtpasswdCredentials returns HtpasswdCredentials for hashes, which maps usernames to htpasswd hashes.
+func NewHtpasswdCredentials(hashes map[string]string) *HtpasswdCredentials {
+	h := &HtpasswdCredentials{hashes: map[string]string{}}
+	cost := 0
+	for username, hash := range hashes {
+		h.hashes[username] = hash
+		if isBcryptHash(hash) {
+			if c, err := bcrypt.Cost([]byte(hash)); err == nil && c > cost {
+				cost = c
+			}
+		}
+	}
+
+	// match the slowest hash in the file, but only pay the bcrypt cost if the file uses bcrypt
+	h.dummy = htpasswdSHA1(randomHex(16))
+	if cost > 0 {
+		if dummy, err := bcrypt.GenerateFromPassword([]byte(randomHex(16)), cost); err == nil {
+			h.dummy = string(dummy)
+		}
+	}
+
+	return h
+}
+
+// LoadHtpasswd reads HtpasswdCredentials from the htpasswd file at path.
+// Blank lines and lines starting with '#' are ignored.
+func LoadHtpasswd(path string) (*HtpasswdCredentials, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hashes := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(text, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d: missing ':' separator", path, line)
+		}
+
+		if !isBcryptHash(parts[1]) && !strings.HasPrefix(parts[1], "{SHA}") {
+			return nil, fmt.Errorf("%s:%d: unsupported hash for user '%s'", path, line, parts[0])
+		}
+
+		hashes[parts[0]] = parts[1]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewHtpasswdCredentials(hashes), nil
+}
+
+// Authenticate returns true if password matches username's hash.
+func (h *HtpasswdCredentials) Authenticate(username, password string) bool {
+	hash, ok := h.hashes[username]
+	if !ok {
+		compareHtpasswdHash(h.dummy, password)
+		return false
+	}
+
+	return compareHtpasswdHash(hash, password)
+}
+
+func compareHtpasswdHash(hash, password string) bool {
+	switch {
+	case isBcryptHash(hash):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, "{SHA}"):
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(htpasswdSHA1(password))) == 1
+	default:
+		return false
+	}
+}
+
+func htpasswdSHA1(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2y$") || strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$")
+}
@@ -0,0 +1,110 @@
+package router
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestStaticCredentials(t *testing.T) {
+	credentials := StaticCredentials{"alice": "pass"}
+
+	assert.True(t, credentials.Authenticate("alice", "pass"))
+	assert.False(t, credentials.Authenticate("alice", "wrong"))
+	assert.False(t, credentials.Authenticate("bob", "pass"))
+	assert.False(t, credentials.Authenticate("bob", ""))
+}
+
+func TestLoadHtpasswd(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("alicepass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := strings.Join([]string{
+		"# comment",
+		"alice:" + strings.Replace(string(hash), "$2a$", "$2y$", 1),
+		"",
+		"bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=",
+	}, "\n")
+
+	dir, err := ioutil.TempDir("", "htpasswd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".htpasswd")
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	credentials, err := LoadHtpasswd(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, credentials.Authenticate("alice", "alicepass"))
+	assert.False(t, credentials.Authenticate("alice", "password"))
+	assert.True(t, credentials.Authenticate("bob", "password"))
+	assert.False(t, credentials.Authenticate("bob", "bobpass"))
+	assert.False(t, credentials.Authenticate("carol", "password"))
+}
+
+func TestLoadHtpasswdUnsupportedHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htpasswd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".htpasswd")
+	if err := ioutil.WriteFile(path, []byte("alice:$apr1$salt$hash"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadHtpasswd(path)
+	assert.Error(t, err)
+}
+
+func TestHtpasswdCredentialsUnknownUser(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("alicepass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		Hashes       map[string]string
+		ExpectedCost int
+	}{
+		"SHA1 only": {
+			Hashes: map[string]string{"bob": "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g="},
+		},
+		"Bcrypt": {
+			Hashes:       map[string]string{"alice": string(hash), "bob": "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g="},
+			ExpectedCost: bcrypt.MinCost,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			credentials := NewHtpasswdCredentials(c.Hashes)
+			if c.ExpectedCost == 0 {
+				assert.True(t, strings.HasPrefix(credentials.dummy, "{SHA}"))
+			} else {
+				cost, err := bcrypt.Cost([]byte(credentials.dummy))
+				if assert.NoError(t, err) {
+					assert.Equal(t, c.ExpectedCost, cost)
+				}
+			}
+
+			assert.False(t, credentials.Authenticate("carol", "alicepass"))
+			assert.False(t, credentials.Authenticate("carol", "password"))
+		})
+	}
+}
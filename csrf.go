@@ -0,0 +1,221 @@
+package router
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrCSRFBadOrigin is the reason a request fails CSRF validation
+	// when its Origin or Referer header does not match the request's host or a trusted origin.
+	ErrCSRFBadOrigin = errors.New("csrf: origin does not match")
+	// ErrCSRFNoReferer is the reason an HTTPS request without Origin or Referer headers fails CSRF validation.
+	ErrCSRFNoReferer = errors.New("csrf: referer not supplied")
+	// ErrCSRFBadToken is the reason a request fails CSRF validation
+	// when the submitted token is missing or does not match the token cookie.
+	ErrCSRFBadToken = errors.New("csrf: token invalid")
+)
+
+// CSRFConfig configures CSRFMiddleware.
+type CSRFConfig struct {
+	// Secret signs the token cookie. It is required, and CSRFMiddleware panics if it is empty.
+	Secret []byte
+	// CookieName is the name of the token cookie. Defaults to "csrf_token".
+	CookieName string
+	// HeaderName is the header a token can be submitted in. Defaults to "X-CSRF-Token".
+	HeaderName string
+	// FormField is the form field a token can be submitted in. Defaults to "csrf_token".
+	FormField string
+	// TrustedOrigins are origins, such as "https://admin.example.com", that are allowed
+	// in addition to the request's host.
+	TrustedOrigins []string
+	// TrustedProxies are the proxies whose X-Forwarded-Proto header is used to determine the request's scheme,
+	// which must match the scheme of the Origin or Referer header.
+	TrustedProxies []*net.IPNet
+	// Secure sets the Secure attribute of the token cookie.
+	Secure bool
+	// Exempt selects routes that do not require CSRF validation.
+	// Exemptions require the matched route, so they only apply when CSRFMiddleware
+	// is applied using RouteMap.ApplyMiddleware or Router.MatchMiddleware.
+	Exempt RouteFilter
+	// FailureHandler handles requests that fail CSRF validation.
+	// The reason can be fetched using CSRFFailureReason.
+	// Defaults to a 403 Status Forbidden response.
+	FailureHandler http.Handler
+}
+
+// CSRFMiddleware returns a Middleware that protects unsafe requests against cross-site request forgery
+// using signed double-submit cookies.
+// Each client is given a token in a signed cookie,
+// and requests with methods other than GET, HEAD, OPTIONS and TRACE
+// must submit the same token in config.HeaderName or config.FormField,
+// and must have an Origin or Referer header that matches the request's scheme and host or one of config.TrustedOrigins.
+// The token can be fetched using CSRFToken, such as for rendering into HTML forms.
+func CSRFMiddleware(config CSRFConfig) Middleware {
+	if len(config.Secret) == 0 {
+		panic("router: CSRFConfig.Secret is required")
+	}
+
+	if config.CookieName == "" {
+		config.CookieName = "csrf_token"
+	}
+
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+
+	if config.FormField == "" {
+		config.FormField = "csrf_token"
+	}
+
+	if config.FailureHandler == nil {
+		config.FailureHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "403 Forbidden", http.StatusForbidden)
+		})
+	}
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := config.cookieToken(r)
+			if !ok {
+				var err error
+				if token, err = newCSRFToken(); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				http.SetCookie(w, &http.Cookie{
+					Name:     config.CookieName,
+					Value:    token + "." + config.sign(token),
+					Path:     "/",
+					HttpOnly: true,
+					Secure:   config.Secure,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			w.Header().Add("Vary", "Cookie")
+			r = r.WithContext(context.WithValue(r.Context(), csrfTokenKey, token))
+
+			if !config.requiresValidation(r) {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			if err := config.validate(r, token, ok); err != nil {
+				ctx := context.WithValue(r.Context(), csrfFailureReasonKey, err)
+				config.FailureHandler.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSRFToken returns the CSRF token for r set by CSRFMiddleware.
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfTokenKey).(string)
+	return token
+}
+
+// CSRFFailureReason returns the reason r failed CSRF validation.
+func CSRFFailureReason(r *http.Request) error {
+	err, _ := r.Context().Value(csrfFailureReasonKey).(error)
+	return err
+}
+
+func (c CSRFConfig) requiresValidation(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+
+	if c.Exempt != nil {
+		if route, ok := MatchedRoute(r); ok && c.Exempt(route.Pattern, route.Method) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c CSRFConfig) validate(r *http.Request, token string, hasCookie bool) error {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if !c.trustedOrigin(r, origin) {
+			return ErrCSRFBadOrigin
+		}
+	} else if referer := r.Header.Get("Referer"); referer != "" {
+		u, err := url.Parse(referer)
+		if err != nil || !c.trustedOrigin(r, u.Scheme+"://"+u.Host) {
+			return ErrCSRFBadOrigin
+		}
+	} else if requestScheme(r, c.TrustedProxies) == "https" {
+		return ErrCSRFNoReferer
+	}
+
+	submitted := r.Header.Get(c.HeaderName)
+	if submitted == "" {
+		submitted = r.PostFormValue(c.FormField)
+	}
+
+	if !hasCookie || submitted == "" || subtle.ConstantTimeCompare([]byte(submitted), []byte(token)) != 1 {
+		return ErrCSRFBadToken
+	}
+
+	return nil
+}
+
+func (c CSRFConfig) trustedOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(u.Scheme, requestScheme(r, c.TrustedProxies)) && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	for _, trusted := range c.TrustedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(trusted, "/"), origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cookieToken returns the token in r's cookie if its signature is valid.
+func (c CSRFConfig) cookieToken(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(c.CookieName)
+	if err != nil {
+		return "", false
+	}
+
+	parts := strings.SplitN(cookie.Value, ".", 2)
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(c.sign(parts[0]))) {
+		return "", false
+	}
+
+	return parts[0], true
+}
+
+func (c CSRFConfig) sign(token string) string {
+	mac := hmac.New(sha256.New, c.Secret)
+	mac.Write([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,191 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	proxies, err := ParseCIDRs("192.0.2.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := CSRFConfig{
+		Secret:         []byte("secret"),
+		TrustedOrigins: []string{"https://admin.example.com"},
+		TrustedProxies: proxies,
+	}
+
+	var token string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = CSRFToken(r)
+	})
+
+	middleware := CSRFMiddleware(config)(handler)
+
+	recorder := httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "http://example.com/form", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotEmpty(t, token)
+
+	cookies := recorder.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+
+	cookie := cookies[0]
+	assert.Equal(t, "csrf_token", cookie.Name)
+	assert.True(t, cookie.HttpOnly)
+
+	forged := &http.Cookie{Name: "csrf_token", Value: "forged." + config.sign("other")}
+	form := url.Values{"csrf_token": {token}}.Encode()
+
+	cases := map[string]struct {
+		Request  func() *http.Request
+		Expected int
+		Reason   error
+	}{
+		"Header token": {
+			Request: func() *http.Request {
+				r := httptest.NewRequest("POST", "http://example.com/form", nil)
+				r.AddCookie(cookie)
+				r.Header.Set("X-CSRF-Token", token)
+				r.Header.Set("Origin", "http://example.com")
+				return r
+			},
+			Expected: http.StatusOK,
+		},
+		"Form token": {
+			Request: func() *http.Request {
+				r := httptest.NewRequest("POST", "http://example.com/form", strings.NewReader(form))
+				r.AddCookie(cookie)
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				r.Header.Set("Referer", "https://admin.example.com/page")
+				return r
+			},
+			Expected: http.StatusOK,
+		},
+		"Missing token": {
+			Request: func() *http.Request {
+				r := httptest.NewRequest("DELETE", "http://example.com/form", nil)
+				r.AddCookie(cookie)
+				return r
+			},
+			Expected: http.StatusForbidden,
+			Reason:   ErrCSRFBadToken,
+		},
+		"Missing cookie": {
+			Request: func() *http.Request {
+				r := httptest.NewRequest("POST", "http://example.com/form", nil)
+				r.Header.Set("X-CSRF-Token", token)
+				return r
+			},
+			Expected: http.StatusForbidden,
+			Reason:   ErrCSRFBadToken,
+		},
+		"Forged cookie": {
+			Request: func() *http.Request {
+				r := httptest.NewRequest("POST", "http://example.com/form", nil)
+				r.AddCookie(forged)
+				r.Header.Set("X-CSRF-Token", "forged")
+				return r
+			},
+			Expected: http.StatusForbidden,
+			Reason:   ErrCSRFBadToken,
+		},
+		"Bad origin": {
+			Request: func() *http.Request {
+				r := httptest.NewRequest("POST", "http://example.com/form", nil)
+				r.AddCookie(cookie)
+				r.Header.Set("X-CSRF-Token", token)
+				r.Header.Set("Origin", "https://evil.com")
+				return r
+			},
+			Expected: http.StatusForbidden,
+			Reason:   ErrCSRFBadOrigin,
+		},
+		"HTTP origin on HTTPS site": {
+			Request: func() *http.Request {
+				r := httptest.NewRequest("POST", "https://example.com/form", nil)
+				r.AddCookie(cookie)
+				r.Header.Set("X-CSRF-Token", token)
+				r.Header.Set("Origin", "http://example.com")
+				return r
+			},
+			Expected: http.StatusForbidden,
+			Reason:   ErrCSRFBadOrigin,
+		},
+		"HTTPS origin behind trusted proxy": {
+			Request: func() *http.Request {
+				r := httptest.NewRequest("POST", "http://example.com/form", nil)
+				r.AddCookie(cookie)
+				r.Header.Set("X-CSRF-Token", token)
+				r.Header.Set("X-Forwarded-Proto", "https")
+				r.Header.Set("Origin", "https://example.com")
+				return r
+			},
+			Expected: http.StatusOK,
+		},
+		"No referer over HTTPS": {
+			Request: func() *http.Request {
+				r := httptest.NewRequest("POST", "https://example.com/form", nil)
+				r.AddCookie(cookie)
+				r.Header.Set("X-CSRF-Token", token)
+				return r
+			},
+			Expected: http.StatusForbidden,
+			Reason:   ErrCSRFNoReferer,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var reason error
+			config := config
+			config.FailureHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reason = CSRFFailureReason(r)
+				w.WriteHeader(http.StatusForbidden)
+			})
+
+			recorder := httptest.NewRecorder()
+			CSRFMiddleware(config)(handler).ServeHTTP(recorder, c.Request())
+			assert.Equal(t, c.Expected, recorder.Code)
+			assert.Equal(t, c.Reason, reason)
+		})
+	}
+}
+
+func TestCSRFMiddlewareRequiresSecret(t *testing.T) {
+	assert.Panics(t, func() {
+		CSRFMiddleware(CSRFConfig{})
+	})
+}
+
+func TestCSRFMiddlewareExempt(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	rm := RouteMap{
+		"/form":    MethodHandlers{http.MethodPost: handler},
+		"/webhook": MethodHandlers{http.MethodPost: handler},
+	}
+
+	rm.ApplyMiddleware(CSRFMiddleware(CSRFConfig{
+		Secret: []byte("secret"),
+		Exempt: PatternFilter("/webhook"),
+	}))
+
+	router := NewRouter(rm.StringMatch())
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("POST", "/form", nil))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("POST", "/webhook", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
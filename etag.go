@@ -0,0 +1,204 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETagConfig configures ETagMiddleware.
+type ETagConfig struct {
+	// MaxBufferSize is the largest response, in bytes, that is buffered to compute an ETag.
+	// Larger responses are streamed without an ETag. Defaults to 1MB.
+	MaxBufferSize int64
+	// Weak causes computed ETags to be weak validators.
+	Weak bool
+	// Validators returns the current ETag and Last-Modified time of the resource for r.
+	// It is used to evaluate If-Match and If-Unmodified-Since headers on unsafe methods,
+	// before the handler is executed.
+	// If nil, preconditions are not evaluated for unsafe methods.
+	Validators func(r *http.Request) (etag string, lastModified time.Time)
+}
+
+// ETagMiddleware returns a Middleware that adds ETags to successful GET and HEAD responses
+// and evaluates conditional requests as described in RFC 7232.
+// Responses are buffered, and an ETag is computed from the body unless the handler set its own ETag header.
+// Handlers can also set a Last-Modified header.
+// GET and HEAD requests whose If-None-Match or If-Modified-Since headers match the response
+// receive a 304 Not Modified response, and requests whose If-Match or If-Unmodified-Since
+// headers do not match receive a 412 Precondition Failed response.
+func ETagMiddleware(config ETagConfig) Middleware {
+	if config.MaxBufferSize <= 0 {
+		config.MaxBufferSize = 1 << 20
+	}
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				if config.Validators != nil {
+					etag, lastModified := config.Validators(r)
+					if status := evaluatePreconditions(r, etag, lastModified); status != 0 {
+						w.WriteHeader(status)
+						return
+					}
+				}
+
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			bw := newBufferedWriter(w, config.MaxBufferSize)
+			handler.ServeHTTP(bw, r)
+			if bw.overflow {
+				return
+			}
+
+			status := bw.Status()
+			if status != http.StatusOK {
+				bw.flush()
+				return
+			}
+
+			header := w.Header()
+			etag := header.Get("ETag")
+			if etag == "" {
+				sum := sha256.Sum256(bw.buf.Bytes())
+				etag = `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+				if config.Weak {
+					etag = "W/" + etag
+				}
+
+				header.Set("ETag", etag)
+			}
+
+			var lastModified time.Time
+			if value := header.Get("Last-Modified"); value != "" {
+				lastModified, _ = http.ParseTime(value)
+			}
+
+			if status := evaluatePreconditions(r, etag, lastModified); status != 0 {
+				if status == http.StatusNotModified {
+					for _, key := range []string{"Content-Type", "Content-Length"} {
+						header.Del(key)
+					}
+				}
+
+				w.WriteHeader(status)
+				return
+			}
+
+			bw.flush()
+		})
+	}
+}
+
+// evaluatePreconditions evaluates r's conditional headers against the resource's current validators.
+// It returns the status code that should be returned instead of executing the request,
+// or 0 if the request should proceed.
+func evaluatePreconditions(r *http.Request, etag string, lastModified time.Time) int {
+	safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if !etagListMatches(ifMatch, etag, true) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Unmodified-Since")); err == nil && !lastModified.IsZero() {
+		if lastModified.Truncate(time.Second).After(since) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if etagListMatches(ifNoneMatch, etag, false) {
+			if safe {
+				return http.StatusNotModified
+			}
+
+			return http.StatusPreconditionFailed
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && safe && !lastModified.IsZero() {
+		if !lastModified.Truncate(time.Second).After(since) {
+			return http.StatusNotModified
+		}
+	}
+
+	return 0
+}
+
+// etagListMatches reports whether etag matches any ETag in list, a comma-separated list or "*".
+// Strong comparison requires both ETags to be strong.
+func etagListMatches(list, etag string, strong bool) bool {
+	if etag == "" {
+		return false
+	}
+
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong {
+			if candidate == etag && !strings.HasPrefix(etag, "W/") {
+				return true
+			}
+
+			continue
+		}
+
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bufferedWriter buffers a response up to max bytes.
+// Larger responses are written through to the underlying http.ResponseWriter.
+type bufferedWriter struct {
+	*statusWriter
+	max      int64
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func newBufferedWriter(w http.ResponseWriter, max int64) *bufferedWriter {
+	return &bufferedWriter{statusWriter: newStatusWriter(w), max: max}
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.setStatus(status)
+}
+
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	if w.overflow {
+		return w.statusWriter.Write(p)
+	}
+
+	if int64(w.buf.Len()+len(p)) > w.max {
+		w.flush()
+		return w.statusWriter.Write(p)
+	}
+
+	return w.buf.Write(p)
+}
+
+// Flush writes the buffered response and stops buffering.
+func (w *bufferedWriter) Flush() {
+	w.flush()
+	w.statusWriter.Flush()
+}
+
+func (w *bufferedWriter) flush() {
+	if w.overflow {
+		return
+	}
+
+	w.overflow = true
+	w.statusWriter.WriteHeader(w.Status())
+	w.statusWriter.Write(w.buf.Bytes())
+}
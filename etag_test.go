@@ -0,0 +1,157 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETagMiddleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`["p1","p2"]`))
+	})
+
+	middleware := ETagMiddleware(ETagConfig{})(handler)
+
+	recorder := httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/products", nil))
+	etag := recorder.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `["p1","p2"]`, recorder.Body.String())
+	assert.True(t, strings.HasPrefix(etag, `"`))
+
+	cases := map[string]struct {
+		Header   string
+		Value    string
+		Expected int
+	}{
+		"If-None-Match match":    {"If-None-Match", etag, http.StatusNotModified},
+		"If-None-Match weak":     {"If-None-Match", `"other", W/` + etag, http.StatusNotModified},
+		"If-None-Match star":     {"If-None-Match", "*", http.StatusNotModified},
+		"If-None-Match mismatch": {"If-None-Match", `"other"`, http.StatusOK},
+		"If-Match match":         {"If-Match", etag, http.StatusOK},
+		"If-Match weak":          {"If-Match", "W/" + etag, http.StatusPreconditionFailed},
+		"If-Match mismatch":      {"If-Match", `"other"`, http.StatusPreconditionFailed},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/products", nil)
+			r.Header.Set(c.Header, c.Value)
+
+			recorder := httptest.NewRecorder()
+			middleware.ServeHTTP(recorder, r)
+			assert.Equal(t, c.Expected, recorder.Code)
+			if c.Expected == http.StatusNotModified {
+				assert.Equal(t, "", recorder.Body.String())
+				assert.Equal(t, etag, recorder.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestETagMiddlewareHandlerValidators(t *testing.T) {
+	modified := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `W/"v1"`)
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		w.Write([]byte("body"))
+	})
+
+	middleware := ETagMiddleware(ETagConfig{})(handler)
+
+	recorder := httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, `W/"v1"`, recorder.Header().Get("ETag"))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-Modified-Since", modified.Format(http.TimeFormat))
+	recorder = httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-Modified-Since", modified.Add(-time.Hour).Format(http.TimeFormat))
+	recorder = httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestETagMiddlewareWeak(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	})
+
+	recorder := httptest.NewRecorder()
+	ETagMiddleware(ETagConfig{Weak: true})(handler).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.True(t, strings.HasPrefix(recorder.Header().Get("ETag"), `W/"`))
+}
+
+func TestETagMiddlewareLargeResponse(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("12345"))
+		w.Write([]byte("67890"))
+	})
+
+	recorder := httptest.NewRecorder()
+	ETagMiddleware(ETagConfig{MaxBufferSize: 8})(handler).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "1234567890", recorder.Body.String())
+	assert.Equal(t, "", recorder.Header().Get("ETag"))
+}
+
+func TestETagMiddlewareErrorResponse(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "missing", http.StatusNotFound)
+	})
+
+	recorder := httptest.NewRecorder()
+	ETagMiddleware(ETagConfig{})(handler).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "missing\n", recorder.Body.String())
+	assert.Equal(t, "", recorder.Header().Get("ETag"))
+}
+
+func TestETagMiddlewareUnsafeMethods(t *testing.T) {
+	var called bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	modified := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	middleware := ETagMiddleware(ETagConfig{
+		Validators: func(r *http.Request) (string, time.Time) {
+			return `"v2"`, modified
+		},
+	})(handler)
+
+	cases := map[string]struct {
+		Header   string
+		Value    string
+		Expected int
+	}{
+		"If-Match match":               {"If-Match", `"v1", "v2"`, http.StatusOK},
+		"If-Match mismatch":            {"If-Match", `"v1"`, http.StatusPreconditionFailed},
+		"If-None-Match match":          {"If-None-Match", `"v2"`, http.StatusPreconditionFailed},
+		"If-Unmodified-Since modified": {"If-Unmodified-Since", modified.Add(-time.Hour).Format(http.TimeFormat), http.StatusPreconditionFailed},
+		"If-Unmodified-Since ok":       {"If-Unmodified-Since", modified.Format(http.TimeFormat), http.StatusOK},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			called = false
+			r := httptest.NewRequest("PUT", "/", nil)
+			r.Header.Set(c.Header, c.Value)
+
+			recorder := httptest.NewRecorder()
+			middleware.ServeHTTP(recorder, r)
+			assert.Equal(t, c.Expected, recorder.Code)
+			assert.Equal(t, c.Expected == http.StatusOK, called)
+		})
+	}
+}
@@ -2,8 +2,10 @@ package router
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 func ExampleSegments() {
@@ -90,6 +92,27 @@ func ExampleNewVariableHandlerMatcher() {
 	// Output: Match successful!
 }
 
+func ExampleMatchedRoute() {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, _ := MatchedRoute(r)
+		fmt.Println(route.Method, route.Pattern, route.Kind)
+	})
+
+	rm := RouteMap{
+		"/products/:productID": MethodHandlers{
+			http.MethodGet: handler,
+		},
+	}
+
+	r := NewRouter(rm.VariableMatch())
+	r.ServeHTTP(nil, &http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{Path: "/products/p582"},
+	})
+
+	// Output: GET /products/:productID variable
+}
+
 func ExampleMiddleware() {
 	myMiddleware := func(h http.Handler) http.Handler {
 		// do some logic here
@@ -110,6 +133,310 @@ func ExampleBasicAuthMiddleware() {
 	rm.ApplyMiddleware(BasicAuthMiddleware("admin", "password"))
 }
 
+func ExampleBasicAuthProviderMiddleware() {
+	credentials := StaticCredentials{
+		"alice": "alicepass",
+		"bob":   "bobpass",
+	}
+
+	rm := RouteMap{}
+	rm.ApplyMiddleware(BasicAuthProviderMiddleware("Admin", credentials))
+}
+
+func ExampleAPIKeyMiddleware() {
+	keys := StaticAPIKeys{
+		"k3y-r34d":  []string{"products:read"},
+		"k3y-wr1t3": []string{"products:read", "products:write"},
+	}
+
+	rm := RouteMap{
+		"/products": MethodHandlers{
+			http.MethodGet:  RequireScopes("products:read")(http.HandlerFunc(nil)),
+			http.MethodPost: RequireScopes("products:write")(http.HandlerFunc(nil)),
+		},
+	}
+
+	rm.ApplyMiddleware(APIKeyMiddleware(keys, APIKeyFromHeader("X-API-Key")))
+}
+
+func ExampleRouteMap_ApplyMiddlewareIf() {
+	rm := RouteMap{
+		"/products": MethodHandlers{
+			http.MethodGet:    http.HandlerFunc(nil),
+			http.MethodDelete: http.HandlerFunc(nil),
+		},
+		"/admin/users": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(nil),
+		},
+	}
+
+	rm.ApplyMiddlewareIf(MethodFilter(http.MethodDelete), BasicAuthMiddleware("user", "pass"))
+	rm.ApplyMiddlewareIf(PatternFilter("/admin/*"), BasicAuthMiddleware("admin", "pass"))
+}
+
+func ExampleChain() {
+	chain := NewChain(LoggingMiddleware(), BasicAuthMiddleware("admin", "password"))
+
+	rm := RouteMap{}
+	rm.ApplyMiddleware(chain.Middleware())
+
+	http.Handle("/health", chain.ThenFunc(func(w http.ResponseWriter, r *http.Request) {}))
+}
+
+func ExampleMetricsMiddleware() {
+	metrics := NewMetrics(DefaultBuckets)
+	rm := RouteMap{
+		"/products": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(nil),
+		},
+	}
+
+	rm.ApplyMiddleware(MetricsMiddleware(metrics))
+	rm["/metrics"] = MethodHandlers{
+		http.MethodGet: metrics,
+	}
+}
+
+func ExampleTracingMiddleware() {
+	rm := RouteMap{}
+	r := NewRouter(rm.VariableMatch())
+	r.Middleware = NewChain(TracingMiddleware(&InMemoryExporter{}))
+}
+
+func ExampleBodyLimitMiddleware() {
+	rm := RouteMap{
+		"/products": MethodHandlers{
+			http.MethodPost: http.HandlerFunc(nil),
+		},
+		"/products/:productID/image": MethodHandlers{
+			http.MethodPut: http.HandlerFunc(nil),
+		},
+	}
+
+	rm.ApplyMiddleware(BodyLimitMiddleware(1<<20, map[string]int64{
+		"/products/:productID/image": 10 << 20,
+	}))
+}
+
+func ExampleSecurityHeadersMiddleware() {
+	config := DefaultSecurityHeadersConfig()
+	config.HSTSPreload = true
+	config.ContentSecurityPolicy = "default-src 'self'; script-src 'nonce-{nonce}'"
+
+	rm := RouteMap{}
+	rm.ApplyMiddleware(SecurityHeadersMiddleware(config))
+}
+
+func ExampleCSRFMiddleware() {
+	rm := RouteMap{
+		"/admin/products": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `<input type="hidden" name="csrf_token" value="%s">`, CSRFToken(r))
+			}),
+			http.MethodPost: http.HandlerFunc(nil),
+		},
+		"/webhooks/payments": MethodHandlers{
+			http.MethodPost: http.HandlerFunc(nil),
+		},
+	}
+
+	rm.ApplyMiddleware(CSRFMiddleware(CSRFConfig{
+		Secret: []byte("change-me"),
+		Secure: true,
+		Exempt: PatternFilter("/webhooks/*"),
+	}))
+}
+
+func ExampleRealIPMiddleware() {
+	trustedProxies, err := ParseCIDRs("10.0.0.0/8", "fd00::/8")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rm := RouteMap{}
+	r := NewRouter(rm.VariableMatch())
+	r.Middleware = NewChain(
+		RealIPMiddleware("X-Forwarded-For", trustedProxies),
+		RateLimitMiddleware(100, time.Minute, RateLimitByIP, NewMemoryRateLimitStore()),
+	)
+}
+
+func ExampleIPFilterMiddleware() {
+	officeVPN, err := ParseCIDRs("10.8.0.0/16", "fd12:3456::/32")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	filter := NewIPFilter(officeVPN, nil)
+
+	admin := RouteMap{}
+	admin.ApplyMiddleware(IPFilterMiddleware(filter))
+
+	// lists can be replaced while the Router is running
+	filter.Set(officeVPN, nil)
+}
+
+func ExampleETagMiddleware() {
+	rm := RouteMap{
+		"/products": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(nil),
+		},
+	}
+
+	rm.ApplyMiddleware(ETagMiddleware(ETagConfig{MaxBufferSize: 256 << 10}))
+}
+
+func ExampleCacheMiddleware() {
+	rm := RouteMap{
+		"/reports/daily": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(nil),
+		},
+	}
+
+	rm.ApplyMiddleware(CacheMiddleware(NewMemoryCache(1000), CacheConfig{DefaultTTL: 5 * time.Second}))
+}
+
+func ExampleConcurrencyLimitMiddleware() {
+	rm := RouteMap{
+		"/reports": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(nil),
+		},
+	}
+
+	// at most 4 reports are generated at once
+	rm.ApplyMiddlewareIf(PatternFilter("/reports"), ConcurrencyLimitMiddleware(NewConcurrencyLimiter(ConcurrencyConfig{
+		Limit:     4,
+		QueueSize: 16,
+		MaxWait:   time.Second,
+	})))
+
+	// at most 500 requests are served at once, adapting to latency
+	r := NewRouter(rm.VariableMatch())
+	r.Middleware = NewChain(ConcurrencyLimitMiddleware(NewConcurrencyLimiter(ConcurrencyConfig{
+		Limit:         500,
+		QueueSize:     1000,
+		MaxWait:       100 * time.Millisecond,
+		TargetLatency: 250 * time.Millisecond,
+	})))
+}
+
+func ExampleCircuitBreakerMiddleware() {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		MinRequests:   10,
+		FailureRatio:  0.5,
+		SlowThreshold: 2 * time.Second,
+		OpenDuration:  15 * time.Second,
+	})
+
+	rm := RouteMap{
+		"/products/:productID/inventory": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(nil),
+		},
+	}
+
+	rm.ApplyMiddleware(CircuitBreakerMiddleware(cb))
+	for route, state := range cb.States() {
+		log.Printf("%s: %s", route, state)
+	}
+}
+
+func ExampleMethodOverrideMiddleware() {
+	rm := RouteMap{
+		"/products/:productID": MethodHandlers{
+			http.MethodDelete: http.HandlerFunc(nil),
+		},
+	}
+
+	// <form method="POST" action="/products/p582"><input type="hidden" name="_method" value="DELETE"></form>
+	r := NewRouter(rm.VariableMatch())
+	r.Middleware = NewChain(MethodOverrideMiddleware(http.MethodDelete))
+}
+
+func ExampleRedirectMiddleware() {
+	loadBalancers, err := ParseCIDRs("10.0.0.0/8")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rm := RouteMap{}
+	r := NewRouter(rm.VariableMatch())
+	r.Middleware = NewChain(RedirectMiddleware(RedirectConfig{
+		HTTPS:          true,
+		CanonicalHost:  "example.com",
+		TrustedProxies: loadBalancers,
+		ExemptPaths:    []string{"/health"},
+	}))
+}
+
+func ExampleParseRouteConfig() {
+	data := []byte(`
+routes:
+  - pattern: /products/:productID
+    method: GET
+    handler: getProduct
+    middleware:
+      - name: logging
+`)
+
+	config, err := ParseRouteConfig(data, "yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	registry := Registry{
+		Handlers: map[string]http.Handler{
+			"getProduct": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("product"))
+			}),
+		},
+		Middleware: map[string]MiddlewareFactory{
+			"logging": func(options map[string]interface{}) (Middleware, error) {
+				return LoggingMiddleware(), nil
+			},
+		},
+	}
+
+	r, err := config.Router(registry)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	http.ListenAndServe(":8080", r)
+}
+
+func ExampleRouter_SetRoutes() {
+	r := NewRouter(nil)
+
+	tenant := RouteMap{
+		"/tenants/acme/:resource": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, "%s for acme", Segment(r.URL.Path, 2))
+			}),
+		},
+	}
+
+	// add the tenant's routes while the router is serving requests
+	r.SetRoutes("acme", tenant.VariableMatch())
+
+	// remove them once the tenant is deleted
+	r.RemoveRoutes("acme")
+}
+
+func ExampleMaintenanceMiddleware() {
+	maintenance := NewMaintenance(MaintenanceConfig{
+		RetryAfter:  10 * time.Minute,
+		Body:        "We'll be right back!",
+		ExemptPaths: []string{"/health"},
+	})
+
+	rm := RouteMap{}
+	r := NewRouter(rm.VariableMatch())
+	r.Middleware = NewChain(MaintenanceMiddleware(maintenance))
+
+	maintenance.Enable()
+	defer maintenance.Disable()
+}
+
 func ExampleRouteMap_GlobMatch() {
 	rm := RouteMap{
 		"/products": MethodHandlers{
@@ -179,3 +506,40 @@ func ExampleRouter() {
 	r := NewRouter(rm.StringMatch())
 	http.Handle("/", r)
 }
+
+func ExampleRouter_middleware() {
+	rm := RouteMap{}
+	r := NewRouter(rm.StringMatch())
+	r.Middleware = NewChain(LoggingMiddleware())
+	r.MatchMiddleware = NewChain(TimeoutMiddleware(5*time.Second, http.StatusServiceUnavailable, ""))
+}
+
+func ExampleTimeoutMiddleware() {
+	rm := RouteMap{}
+
+	// nested timeouts use the shortest deadline, so apply each timeout to a separate set of routes
+	reports := PatternFilter("/reports/*")
+	rm.ApplyMiddlewareIf(reports, TimeoutMiddleware(time.Minute, http.StatusGatewayTimeout, "report timed out\n"))
+	rm.ApplyMiddlewareIf(func(pattern, method string) bool { return !reports(pattern, method) },
+		TimeoutMiddleware(5*time.Second, http.StatusServiceUnavailable, "request timed out\n"))
+}
+
+func ExampleRateLimitMiddleware() {
+	rm := RouteMap{}
+	rm.ApplyMiddleware(RateLimitMiddleware(100, time.Minute, RateLimitByRoute(RateLimitByIP), NewMemoryRateLimitStore()))
+}
+
+func ExampleBearerAuthMiddleware() {
+	keys, err := LoadJWKS("jwks.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rm := RouteMap{}
+	rm.ApplyMiddleware(BearerAuthMiddleware(JWTConfig{
+		Keys:      keys,
+		Issuer:    "https://auth.example.com",
+		Audience:  "products-api",
+		ClockSkew: time.Minute,
+	}))
+}
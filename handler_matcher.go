@@ -9,12 +9,15 @@ import (
 )
 
 // A HandlerMatcher is a function that matches a *http.Request to a http.Handler.
+// The handlers returned by the HandlerMatcher constructors in this package
+// record the matched Route, which can be fetched using MatchedRoute.
 type HandlerMatcher func(r *http.Request) (handler http.Handler, matchFound bool)
 
 // NewGlobHandlerMatcher returns a HandlerMatcher that returns a match if and only if
 // the request.Method matches method,
 // and the request.URL.Path glob matches pattern.
 func NewGlobHandlerMatcher(method, pattern string, handler http.Handler) HandlerMatcher {
+	handler = newRouteHandler("glob", method, pattern, handler)
 	return func(r *http.Request) (http.Handler, bool) {
 		if r.Method == method && glob.Glob(pattern, r.URL.Path) {
 			return handler, true
@@ -29,6 +32,7 @@ func NewGlobHandlerMatcher(method, pattern string, handler http.Handler) Handler
 // and the request.URL.Path regex matches pattern.
 func NewRegexHandlerMatcher(method, pattern string, handler http.Handler) HandlerMatcher {
 	re := regexp.MustCompile(pattern)
+	handler = newRouteHandler("regex", method, pattern, handler)
 	return func(r *http.Request) (http.Handler, bool) {
 		if r.Method == method && re.MatchString(r.URL.Path) {
 			return handler, true
@@ -42,6 +46,7 @@ func NewRegexHandlerMatcher(method, pattern string, handler http.Handler) Handle
 // the request.Method matches method,
 // and the request.URL.Path matches pattern.
 func NewStringHandlerMatcher(method, pattern string, handler http.Handler) HandlerMatcher {
+	handler = newRouteHandler("string", method, pattern, handler)
 	return func(r *http.Request) (http.Handler, bool) {
 		if r.Method == method && r.URL.Path == pattern {
 			return handler, true
@@ -62,6 +67,7 @@ func NewStringHandlerMatcher(method, pattern string, handler http.Handler) Handl
 //   NewGlobHandlerMatcher(http.MethodGet, "/product/*/", handler)
 func NewVariableHandlerMatcher(method, pattern string, handler http.Handler) HandlerMatcher {
 	patternSegments := Segments(pattern)
+	handler = newRouteHandler("variable", method, pattern, handler)
 	return func(r *http.Request) (http.Handler, bool) {
 		if r.Method != method {
 			return nil, false
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"sort"
+	"sync/atomic"
+)
+
+// IPFilter allows or denies requests based on the client's IP address.
+// Its lists can be replaced using Set while requests are being served.
+type IPFilter struct {
+	rules atomic.Value
+}
+
+type ipFilterRules struct {
+	allow ipRanges
+	deny  ipRanges
+}
+
+// NewIPFilter returns an IPFilter with the specified allow and deny lists.
+func NewIPFilter(allow, deny []*net.IPNet) *IPFilter {
+	f := &IPFilter{}
+	f.Set(allow, deny)
+	return f
+}
+
+// Set atomically replaces the allow and deny lists of f.
+func (f *IPFilter) Set(allow, deny []*net.IPNet) {
+	f.rules.Store(&ipFilterRules{
+		allow: newIPRanges(allow),
+		deny:  newIPRanges(deny),
+	})
+}
+
+// Allowed reports whether ip is allowed by f.
+// IP addresses in the deny list are never allowed.
+// If the allow list is empty, all other IP addresses are allowed;
+// otherwise, only IP addresses in the allow list are allowed.
+func (f *IPFilter) Allowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	rules := f.rules.Load().(*ipFilterRules)
+	if rules.deny.contains(ip) {
+		return false
+	}
+
+	return len(rules.allow) == 0 || rules.allow.contains(ip)
+}
+
+// IPFilterMiddleware returns a Middleware that requires the client's IP address,
+// as returned by ClientIP, to be allowed by f before the original handler is executed.
+// Otherwise, a 403 Status Forbidden response is returned.
+func IPFilterMiddleware(f *IPFilter) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !f.Allowed(net.ParseIP(ClientIP(r))) {
+				http.Error(w, "403 Forbidden", http.StatusForbidden)
+				return
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ipRanges is a sorted list of non-overlapping IP address ranges,
+// which allows large lists to be searched in logarithmic time.
+// IPv4 addresses are stored in their IPv6-mapped form.
+type ipRanges []ipRange
+
+type ipRange struct {
+	start net.IP
+	end   net.IP
+}
+
+func newIPRanges(networks []*net.IPNet) ipRanges {
+	ranges := make(ipRanges, 0, len(networks))
+	for _, network := range networks {
+		start := network.IP.Mask(network.Mask).To16()
+		end := make(net.IP, net.IPv6len)
+		mask := network.Mask
+		if len(mask) == net.IPv4len {
+			mask = append(net.CIDRMask(96, 128)[:12], mask...)
+		}
+
+		for i := range end {
+			end[i] = start[i] | ^mask[i]
+		}
+
+		ranges = append(ranges, ipRange{start: start, end: end})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].start, ranges[j].start) < 0
+	})
+
+	merged := ipRanges{}
+	for _, r := range ranges {
+		last := len(merged) - 1
+		if last >= 0 && bytes.Compare(r.start, merged[last].end) <= 0 {
+			if bytes.Compare(r.end, merged[last].end) > 0 {
+				merged[last].end = r.end
+			}
+
+			continue
+		}
+
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+func (rs ipRanges) contains(ip net.IP) bool {
+	ip = ip.To16()
+	i := sort.Search(len(rs), func(i int) bool {
+		return bytes.Compare(rs[i].end, ip) >= 0
+	})
+
+	return i < len(rs) && bytes.Compare(rs[i].start, ip) <= 0
+}
@@ -0,0 +1,108 @@
+package router
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParseCIDRs(t *testing.T, cidrs ...string) []*net.IPNet {
+	networks, err := ParseCIDRs(cidrs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return networks
+}
+
+func TestIPFilterAllowed(t *testing.T) {
+	filter := NewIPFilter(
+		mustParseCIDRs(t, "10.0.0.0/8", "192.168.0.0/16", "10.1.0.0/16", "2001:db8::/32"),
+		mustParseCIDRs(t, "10.0.0.13", "2001:db8:bad::/48"),
+	)
+
+	cases := map[string]bool{
+		"10.0.0.1":        true,
+		"10.255.255.255":  true,
+		"10.0.0.13":       false,
+		"11.0.0.0":        false,
+		"9.255.255.255":   false,
+		"192.168.4.4":     true,
+		"2001:db8::1":     true,
+		"2001:db8:bad::1": false,
+		"2001:db9::1":     false,
+		"::ffff:10.0.0.1": true,
+	}
+
+	for ip, expected := range cases {
+		t.Run(ip, func(t *testing.T) {
+			assert.Equal(t, expected, filter.Allowed(net.ParseIP(ip)))
+		})
+	}
+
+	assert.False(t, filter.Allowed(nil))
+}
+
+func TestIPFilterEmptyAllowList(t *testing.T) {
+	filter := NewIPFilter(nil, mustParseCIDRs(t, "203.0.113.0/24"))
+	assert.True(t, filter.Allowed(net.ParseIP("198.51.100.1")))
+	assert.False(t, filter.Allowed(net.ParseIP("203.0.113.7")))
+}
+
+func TestIPFilterLargeList(t *testing.T) {
+	cidrs := []string{}
+	for i := 0; i < 256; i++ {
+		for j := 0; j < 256; j += 2 {
+			cidrs = append(cidrs, fmt.Sprintf("10.%d.%d.0/24", i, j))
+		}
+	}
+
+	filter := NewIPFilter(mustParseCIDRs(t, cidrs...), nil)
+	assert.True(t, filter.Allowed(net.ParseIP("10.200.100.1")))
+	assert.False(t, filter.Allowed(net.ParseIP("10.200.101.1")))
+}
+
+func TestIPFilterMiddleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	filter := NewIPFilter(mustParseCIDRs(t, "10.0.0.0/8"), nil)
+	middleware := IPFilterMiddleware(filter)(handler)
+
+	r := httptest.NewRequest("GET", "/admin", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+
+	recorder := httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	filter.Set(mustParseCIDRs(t, "192.168.0.0/16"), nil)
+	recorder = httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestIPFilterConcurrentSet(t *testing.T) {
+	allow := mustParseCIDRs(t, "10.0.0.0/8")
+	filter := NewIPFilter(allow, nil)
+	ip := net.ParseIP("10.0.0.1")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			filter.Set(allow, nil)
+		}()
+
+		go func() {
+			defer wg.Done()
+			assert.True(t, filter.Allowed(ip))
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,329 @@
+package router
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// JWTClaims are the claims in a JSON Web Token's payload.
+type JWTClaims map[string]interface{}
+
+// A JWTKeySet maps key IDs to the keys used to verify JSON Web Token signatures.
+// HS256 tokens are verified with []byte keys, RS256 tokens with *rsa.PublicKey keys,
+// and ES256 tokens with *ecdsa.PublicKey keys.
+// Tokens without a "kid" header are verified with the key stored under "",
+// or with the only key in the set.
+type JWTKeySet map[string]interface{}
+
+// LoadJWKS reads a JWTKeySet from the JSON Web Key Set file at path.
+// RSA, EC (P-256) and oct keys are supported.
+func LoadJWKS(path string) (JWTKeySet, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := JWTKeySet{}
+	for i, jwk := range jwks.Keys {
+		var key interface{}
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			var n, e *big.Int
+			if n, err = decodeBigInt(jwk.N); err == nil {
+				if e, err = decodeBigInt(jwk.E); err == nil {
+					key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+				}
+			}
+		case "EC":
+			if jwk.Crv != "P-256" {
+				err = fmt.Errorf("unsupported curve '%s'", jwk.Crv)
+				break
+			}
+
+			var x, y *big.Int
+			if x, err = decodeBigInt(jwk.X); err == nil {
+				if y, err = decodeBigInt(jwk.Y); err == nil {
+					key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+				}
+			}
+		case "oct":
+			key, err = base64.RawURLEncoding.DecodeString(jwk.K)
+		default:
+			err = fmt.Errorf("unsupported key type '%s'", jwk.Kty)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %d: %v", path, i, err)
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// JWTConfig configures BearerAuthMiddleware.
+type JWTConfig struct {
+	// Keys verify the tokens' signatures.
+	Keys JWTKeySet
+	// Issuer, if set, must match the tokens' "iss" claim.
+	Issuer string
+	// Audience, if set, must be contained in the tokens' "aud" claim.
+	Audience string
+	// ClockSkew is the leeway allowed when checking the "exp" and "nbf" claims.
+	ClockSkew time.Duration
+	// Realm is used in the WWW-Authenticate header of error responses.
+	Realm string
+}
+
+// BearerAuthMiddleware returns a Middleware that requires requests to have an
+// "Authorization: Bearer <token>" header containing a valid HS256, RS256 or ES256 JSON Web Token
+// before the original handler is executed.
+// The token's claims can be fetched using BearerClaims.
+// Otherwise, an error response with a WWW-Authenticate header as described in RFC 6750 is returned.
+func BearerAuthMiddleware(config JWTConfig) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := r.Header.Get("Authorization")
+			if auth == "" {
+				writeBearerError(w, config.Realm, http.StatusUnauthorized, "", "")
+				return
+			}
+
+			if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+				writeBearerError(w, config.Realm, http.StatusBadRequest, "invalid_request", "malformed Authorization header")
+				return
+			}
+
+			claims, err := config.verify(strings.TrimSpace(auth[7:]), time.Now())
+			if err != nil {
+				writeBearerError(w, config.Realm, http.StatusUnauthorized, "invalid_token", err.Error())
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), bearerClaimsKey, claims)
+			handler.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// BearerClaims returns the claims of the token validated by BearerAuthMiddleware.
+func BearerClaims(r *http.Request) (JWTClaims, bool) {
+	claims, ok := r.Context().Value(bearerClaimsKey).(JWTClaims)
+	return claims, ok
+}
+
+func writeBearerError(w http.ResponseWriter, realm string, status int, code, description string) {
+	params := []string{}
+	if realm != "" {
+		params = append(params, fmt.Sprintf("realm=%q", realm))
+	}
+
+	if code != "" {
+		params = append(params, fmt.Sprintf("error=%q", code))
+	}
+
+	if description != "" {
+		params = append(params, fmt.Sprintf("error_description=%q", description))
+	}
+
+	challenge := "Bearer"
+	if len(params) > 0 {
+		challenge += " " + strings.Join(params, ", ")
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, fmt.Sprintf("%d %s", status, http.StatusText(status)), status)
+}
+
+func (c JWTConfig) verify(token string, now time.Time) (JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, errors.New("malformed header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+
+	key, ok := c.Keys[header.Kid]
+	if !ok && header.Kid == "" && len(c.Keys) == 1 {
+		for _, k := range c.Keys {
+			key, ok = k, true
+		}
+	}
+
+	if !ok {
+		return nil, errors.New("unknown key")
+	}
+
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	claims := JWTClaims{}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, errors.New("malformed claims")
+	}
+
+	exp, ok, err := numericDateClaim(claims, "exp")
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && !now.Before(exp.Add(c.ClockSkew)) {
+		return nil, errors.New("token is expired")
+	}
+
+	nbf, ok, err := numericDateClaim(claims, "nbf")
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && now.Before(nbf.Add(-c.ClockSkew)) {
+		return nil, errors.New("token is not valid yet")
+	}
+
+	if c.Issuer != "" && claims["iss"] != c.Issuer {
+		return nil, errors.New("invalid issuer")
+	}
+
+	if c.Audience != "" && !hasAudience(claims["aud"], c.Audience) {
+		return nil, errors.New("invalid audience")
+	}
+
+	return claims, nil
+}
+
+// numericDateClaim returns the time of the NumericDate claim with the specified name.
+// It returns an error if the claim is present but is not a number.
+func numericDateClaim(claims JWTClaims, name string) (time.Time, bool, error) {
+	value, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("malformed %s claim", name)
+	}
+
+	return time.Unix(int64(seconds), 0), true, nil
+}
+
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// verifyJWTSignature checks signature using key.
+// The key's type must match alg so that, for example, an RSA public key
+// cannot be used as an HMAC secret.
+func verifyJWTSignature(alg string, key interface{}, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	invalid := errors.New("invalid signature")
+
+	switch alg {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok {
+			return invalid
+		}
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return invalid
+		}
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+			return invalid
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return invalid
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return invalid
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm '%s'", alg)
+	}
+
+	return nil
+}
+
+func hasAudience(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
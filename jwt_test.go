@@ -0,0 +1,215 @@
+package router
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func signJWT(t *testing.T, alg, kid string, key interface{}, claims JWTClaims) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestBearerAuthMiddleware(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	secret := []byte("secret")
+
+	config := JWTConfig{
+		Keys: JWTKeySet{
+			"hs":  secret,
+			"rsa": &rsaKey.PublicKey,
+			"ec":  &ecKey.PublicKey,
+		},
+		Issuer:    "issuer",
+		Audience:  "api",
+		ClockSkew: time.Minute,
+		Realm:     "example",
+	}
+
+	now := time.Now().Unix()
+	valid := JWTClaims{"sub": "alice", "iss": "issuer", "aud": []string{"web", "api"}, "exp": now + 60}
+
+	cases := map[string]struct {
+		Authorization string
+		Status        int
+		Challenge     string
+	}{
+		"HS256": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "hs", secret, valid),
+			Status:        http.StatusOK,
+		},
+		"RS256": {
+			Authorization: "Bearer " + signJWT(t, "RS256", "rsa", rsaKey, valid),
+			Status:        http.StatusOK,
+		},
+		"ES256": {
+			Authorization: "bearer " + signJWT(t, "ES256", "ec", ecKey, valid),
+			Status:        http.StatusOK,
+		},
+		"Within clock skew": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "hs", secret, JWTClaims{"iss": "issuer", "aud": "api", "exp": now - 30, "nbf": now + 30}),
+			Status:        http.StatusOK,
+		},
+		"Missing token": {
+			Status:    http.StatusUnauthorized,
+			Challenge: `Bearer realm="example"`,
+		},
+		"Malformed header": {
+			Authorization: "Basic abc",
+			Status:        http.StatusBadRequest,
+			Challenge:     `Bearer realm="example", error="invalid_request", error_description="malformed Authorization header"`,
+		},
+		"Expired": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "hs", secret, JWTClaims{"iss": "issuer", "aud": "api", "exp": now - 120}),
+			Status:        http.StatusUnauthorized,
+			Challenge:     `Bearer realm="example", error="invalid_token", error_description="token is expired"`,
+		},
+		"Expired at skew boundary": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "hs", secret, JWTClaims{"iss": "issuer", "aud": "api", "exp": now - 60}),
+			Status:        http.StatusUnauthorized,
+			Challenge:     `Bearer realm="example", error="invalid_token", error_description="token is expired"`,
+		},
+		"String exp": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "hs", secret, JWTClaims{"iss": "issuer", "aud": "api", "exp": "1700000000"}),
+			Status:        http.StatusUnauthorized,
+			Challenge:     `Bearer realm="example", error="invalid_token", error_description="malformed exp claim"`,
+		},
+		"String nbf": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "hs", secret, JWTClaims{"iss": "issuer", "aud": "api", "nbf": "1700000000"}),
+			Status:        http.StatusUnauthorized,
+		},
+		"Not valid yet": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "hs", secret, JWTClaims{"iss": "issuer", "aud": "api", "nbf": now + 120}),
+			Status:        http.StatusUnauthorized,
+		},
+		"Wrong issuer": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "hs", secret, JWTClaims{"iss": "other", "aud": "api"}),
+			Status:        http.StatusUnauthorized,
+		},
+		"Wrong audience": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "hs", secret, JWTClaims{"iss": "issuer", "aud": "web"}),
+			Status:        http.StatusUnauthorized,
+		},
+		"Wrong key": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "hs", []byte("guess"), valid),
+			Status:        http.StatusUnauthorized,
+			Challenge:     `Bearer realm="example", error="invalid_token", error_description="invalid signature"`,
+		},
+		"Algorithm mismatch": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "rsa", secret, valid),
+			Status:        http.StatusUnauthorized,
+		},
+		"Unknown key": {
+			Authorization: "Bearer " + signJWT(t, "HS256", "other", secret, valid),
+			Status:        http.StatusUnauthorized,
+		},
+		"Unsupported algorithm": {
+			Authorization: "Bearer " + signJWT(t, "none", "hs", nil, valid),
+			Status:        http.StatusUnauthorized,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var claims JWTClaims
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				claims, _ = BearerClaims(r)
+			})
+
+			recorder := httptest.NewRecorder()
+			r := NewRequest("GET", "/")
+			r.Header = http.Header{}
+			if c.Authorization != "" {
+				r.Header.Set("Authorization", c.Authorization)
+			}
+
+			BearerAuthMiddleware(config)(handler).ServeHTTP(recorder, r)
+			assert.Equal(t, c.Status, recorder.Code)
+			if c.Challenge != "" {
+				assert.Equal(t, c.Challenge, recorder.Header().Get("WWW-Authenticate"))
+			}
+
+			if c.Status == http.StatusOK {
+				assert.Equal(t, "issuer", claims["iss"])
+			} else {
+				assert.Nil(t, claims)
+			}
+		})
+	}
+}
+
+func TestLoadJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	jwks := fmt.Sprintf(`{"keys": [
+		{"kid": "rsa", "kty": "RSA", "n": "%s", "e": "AQAB"},
+		{"kid": "ec", "kty": "EC", "crv": "P-256", "x": "%s", "y": "%s"},
+		{"kid": "hs", "kty": "oct", "k": "%s"}
+	]}`, encode(rsaKey.N.Bytes()), encode(ecKey.X.Bytes()), encode(ecKey.Y.Bytes()), encode([]byte("secret")))
+
+	dir, err := ioutil.TempDir("", "jwks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "jwks.json")
+	if err := ioutil.WriteFile(path, []byte(jwks), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := LoadJWKS(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, &rsaKey.PublicKey, keys["rsa"])
+	assert.True(t, ecKey.PublicKey.Equal(keys["ec"]))
+	assert.Equal(t, []byte("secret"), keys["hs"])
+}
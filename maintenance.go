@@ -0,0 +1,102 @@
+package router
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	glob "github.com/ryanuber/go-glob"
+)
+
+// MaintenanceConfig configures a Maintenance switch.
+type MaintenanceConfig struct {
+	// RetryAfter is the value of the Retry-After header in maintenance responses.
+	RetryAfter time.Duration
+	// Body is the body of maintenance responses. Defaults to "503 Service Unavailable".
+	Body string
+	// ExemptPaths are glob patterns for request paths that are served during maintenance, such as "/health".
+	ExemptPaths []string
+	// AllowedIPs are networks whose requests are served during maintenance.
+	// Requests are checked using ClientIP.
+	AllowedIPs []*net.IPNet
+	// Tokens are values of the X-Maintenance-Token header that allow requests to be served during maintenance.
+	Tokens []string
+}
+
+// A Maintenance switch puts the handlers it is applied to into maintenance mode.
+// It can be enabled and disabled while requests are being served.
+type Maintenance struct {
+	config  MaintenanceConfig
+	enabled int32
+}
+
+// NewMaintenance returns a disabled Maintenance switch with the specified config.
+func NewMaintenance(config MaintenanceConfig) *Maintenance {
+	if config.Body == "" {
+		config.Body = "503 Service Unavailable"
+	}
+
+	return &Maintenance{config: config}
+}
+
+// Enable puts m into maintenance mode.
+func (m *Maintenance) Enable() {
+	atomic.StoreInt32(&m.enabled, 1)
+}
+
+// Disable takes m out of maintenance mode.
+func (m *Maintenance) Disable() {
+	atomic.StoreInt32(&m.enabled, 0)
+}
+
+// Enabled reports whether m is in maintenance mode.
+func (m *Maintenance) Enabled() bool {
+	return atomic.LoadInt32(&m.enabled) == 1
+}
+
+// MaintenanceMiddleware returns a Middleware that returns a 503 Service Unavailable response
+// with a Retry-After header while m is enabled,
+// unless the request is exempt, from an allowed IP address, or has a valid token.
+// A whole Router can be put into maintenance mode by using MaintenanceMiddleware as Router.Middleware,
+// and groups of routes by applying separate Maintenance switches to separate RouteMaps.
+func MaintenanceMiddleware(m *Maintenance) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !m.Enabled() || m.allowed(r) {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			if m.config.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(m.config.RetryAfter)))
+			}
+
+			http.Error(w, m.config.Body, http.StatusServiceUnavailable)
+		})
+	}
+}
+
+func (m *Maintenance) allowed(r *http.Request) bool {
+	for _, pattern := range m.config.ExemptPaths {
+		if glob.Glob(pattern, r.URL.Path) {
+			return true
+		}
+	}
+
+	if ipInNetworks(net.ParseIP(ClientIP(r)), m.config.AllowedIPs) {
+		return true
+	}
+
+	if token := r.Header.Get("X-Maintenance-Token"); token != "" {
+		for _, t := range m.config.Tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	office, err := ParseCIDRs("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMaintenance(MaintenanceConfig{
+		RetryAfter:  time.Minute,
+		Body:        "down for maintenance",
+		ExemptPaths: []string{"/health"},
+		AllowedIPs:  office,
+		Tokens:      []string{"t0k3n"},
+	})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	middleware := MaintenanceMiddleware(m)(handler)
+
+	recorder := httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/products", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	m.Enable()
+	assert.True(t, m.Enabled())
+
+	cases := map[string]struct {
+		Path       string
+		RemoteAddr string
+		Token      string
+		Expected   int
+	}{
+		"Blocked":       {Path: "/products", Expected: http.StatusServiceUnavailable},
+		"Exempt path":   {Path: "/health", Expected: http.StatusOK},
+		"Allowed IP":    {Path: "/products", RemoteAddr: "10.1.2.3:1234", Expected: http.StatusOK},
+		"Valid token":   {Path: "/products", Token: "t0k3n", Expected: http.StatusOK},
+		"Invalid token": {Path: "/products", Token: "guess", Expected: http.StatusServiceUnavailable},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", c.Path, nil)
+			if c.RemoteAddr != "" {
+				r.RemoteAddr = c.RemoteAddr
+			}
+
+			if c.Token != "" {
+				r.Header.Set("X-Maintenance-Token", c.Token)
+			}
+
+			recorder := httptest.NewRecorder()
+			middleware.ServeHTTP(recorder, r)
+			assert.Equal(t, c.Expected, recorder.Code)
+			if c.Expected == http.StatusServiceUnavailable {
+				assert.Equal(t, "60", recorder.Header().Get("Retry-After"))
+				assert.Equal(t, "down for maintenance\n", recorder.Body.String())
+			}
+		})
+	}
+
+	m.Disable()
+	recorder = httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, httptest.NewRequest("GET", "/products", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestMaintenanceConcurrentToggle(t *testing.T) {
+	m := NewMaintenance(MaintenanceConfig{})
+	router := NewRouter(nil)
+	router.Middleware = NewChain(MaintenanceMiddleware(m))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				m.Enable()
+			} else {
+				m.Disable()
+			}
+		}(i)
+
+		go func() {
+			defer wg.Done()
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		}()
+	}
+
+	wg.Wait()
+}
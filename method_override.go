@@ -0,0 +1,50 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// methodOverrideMaxFormSize is the largest form body MethodOverrideMiddleware reads the "_method" field from.
+const methodOverrideMaxFormSize = 64 << 10
+
+// MethodOverrideMiddleware returns a Middleware that rewrites the method of POST requests
+// to the method in the X-HTTP-Method-Override header or the "_method" form field,
+// which allows HTML forms to reach PUT, PATCH and DELETE handlers.
+// The "_method" field is only read from application/x-www-form-urlencoded bodies of up to 64KB
+// with a Content-Length, so that large bodies are not read before per-route limits such as
+// BodyLimitMiddleware are applied. Multipart forms must use the X-HTTP-Method-Override header.
+// Only the specified methods are allowed as overrides; if none are specified,
+// PUT, PATCH and DELETE are allowed.
+// Since the method must be rewritten before matching, MethodOverrideMiddleware should be used as Router.Middleware.
+func MethodOverrideMiddleware(methods ...string) Middleware {
+	if len(methods) == 0 {
+		methods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				override := r.Header.Get("X-HTTP-Method-Override")
+				if override == "" && isURLEncodedForm(r) {
+					override = r.PostFormValue("_method")
+				}
+
+				override = strings.ToUpper(strings.TrimSpace(override))
+				if override != "" && containsString(methods, override) {
+					// copy r rather than modifying the caller's request
+					r2 := *r
+					r2.Method = override
+					r = &r2
+				}
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isURLEncodedForm(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") &&
+		r.ContentLength >= 0 && r.ContentLength <= methodOverrideMaxFormSize
+}
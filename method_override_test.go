@@ -0,0 +1,146 @@
+package router
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodOverrideMiddleware(t *testing.T) {
+	cases := map[string]struct {
+		Method   string
+		Header   string
+		Form     string
+		Allowed  []string
+		Expected string
+	}{
+		"Header": {
+			Method:   "POST",
+			Header:   "DELETE",
+			Expected: "DELETE",
+		},
+		"Form field": {
+			Method:   "POST",
+			Form:     "_method=put&name=p1",
+			Expected: "PUT",
+		},
+		"Lowercase": {
+			Method:   "POST",
+			Form:     "_method=patch",
+			Expected: "PATCH",
+		},
+		"Not allowed": {
+			Method:   "POST",
+			Header:   "CONNECT",
+			Expected: "POST",
+		},
+		"Custom allowlist": {
+			Method:   "POST",
+			Header:   "PUT",
+			Allowed:  []string{"DELETE"},
+			Expected: "POST",
+		},
+		"Not POST": {
+			Method:   "GET",
+			Header:   "DELETE",
+			Expected: "GET",
+		},
+		"No override": {
+			Method:   "POST",
+			Expected: "POST",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var method string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method = r.Method
+			})
+
+			r := httptest.NewRequest(c.Method, "/products/p1", strings.NewReader(c.Form))
+			if c.Header != "" {
+				r.Header.Set("X-HTTP-Method-Override", c.Header)
+			}
+
+			if c.Form != "" {
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+
+			MethodOverrideMiddleware(c.Allowed...)(handler).ServeHTTP(httptest.NewRecorder(), r)
+			assert.Equal(t, c.Expected, method)
+		})
+	}
+}
+
+func TestMethodOverrideMiddlewareDoesNotModifyRequest(t *testing.T) {
+	var method string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+	})
+
+	r := httptest.NewRequest("POST", "/products/p1", nil)
+	r.Header.Set("X-HTTP-Method-Override", "DELETE")
+	MethodOverrideMiddleware()(handler).ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "DELETE", method)
+	assert.Equal(t, "POST", r.Method)
+}
+
+func TestMethodOverrideMiddlewareLargeBodies(t *testing.T) {
+	cases := map[string]struct {
+		ContentType string
+		Body        string
+	}{
+		"Multipart": {
+			ContentType: "multipart/form-data; boundary=b",
+			Body:        "--b\r\nContent-Disposition: form-data; name=\"_method\"\r\n\r\nPUT\r\n--b--\r\n",
+		},
+		"Large form": {
+			ContentType: "application/x-www-form-urlencoded",
+			Body:        "_method=PUT&data=" + strings.Repeat("a", methodOverrideMaxFormSize),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var method string
+			var body []byte
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method = r.Method
+				body, _ = ioutil.ReadAll(r.Body)
+			})
+
+			r := httptest.NewRequest("POST", "/upload", strings.NewReader(c.Body))
+			r.Header.Set("Content-Type", c.ContentType)
+			MethodOverrideMiddleware()(handler).ServeHTTP(httptest.NewRecorder(), r)
+			assert.Equal(t, "POST", method)
+			assert.Equal(t, c.Body, string(body))
+		})
+	}
+}
+
+func TestMethodOverrideMiddlewareRouter(t *testing.T) {
+	var deleted bool
+	rm := RouteMap{
+		"/products/:productID": MethodHandlers{
+			http.MethodDelete: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				deleted = true
+			}),
+		},
+	}
+
+	router := NewRouter(rm.VariableMatch())
+	router.Middleware = NewChain(MethodOverrideMiddleware())
+
+	r := httptest.NewRequest("POST", "/products/p1", strings.NewReader("_method=DELETE"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, deleted)
+}
@@ -0,0 +1,226 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the default latency histogram buckets, in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics collects per-route request counts, latencies and in-flight requests.
+// Metrics is a http.Handler that writes the collected metrics in the Prometheus text exposition format,
+// so it can be mounted in a RouteMap:
+//   rm := RouteMap{
+//           "/metrics": MethodHandlers{
+//                   http.MethodGet: metrics,
+//           },
+//   }
+type Metrics struct {
+	buckets []float64
+
+	mu       sync.Mutex
+	requests map[metricsKey]*requestMetrics
+	inFlight map[metricsKey]int64
+}
+
+type metricsKey struct {
+	route  string
+	method string
+	status string
+}
+
+type requestMetrics struct {
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+// NewMetrics returns an empty Metrics that uses the specified latency histogram buckets, in seconds.
+// If buckets is nil, DefaultBuckets is used.
+func NewMetrics(buckets []float64) *Metrics {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+
+	return &Metrics{
+		buckets:  sorted,
+		requests: map[metricsKey]*requestMetrics{},
+		inFlight: map[metricsKey]int64{},
+	}
+}
+
+// MetricsMiddleware returns a Middleware that records requests in m.
+// Requests are labeled by their matched route pattern, method, and status class (e.g. "2xx").
+// Methods other than the standard HTTP methods are labeled "other", so that clients cannot create new series.
+// MetricsMiddleware can be used as Router.Middleware, which also counts requests handled by Router.NotFound,
+// or applied using RouteMap.ApplyMiddleware or Router.MatchMiddleware.
+// Requests without a matched route are labeled with the route "unmatched".
+// Since the route is not known until a request has been matched,
+// in-flight requests are labeled "unmatched" when MetricsMiddleware is used as Router.Middleware.
+func MetricsMiddleware(m *Metrics) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method := metricsMethod(r.Method)
+			flightKey := metricsKey{route: "unmatched", method: method}
+			if matched, ok := MatchedRoute(r); ok {
+				flightKey.route = matched.Pattern
+			}
+
+			m.mu.Lock()
+			m.inFlight[flightKey]++
+			m.mu.Unlock()
+
+			r, recorder := recordRoute(r)
+			start := time.Now()
+			sw := newStatusWriter(w)
+			defer func() {
+				key := flightKey
+				if route, ok := recorder.Route(); ok {
+					key.route = route.Pattern
+				}
+
+				m.observe(flightKey, key, sw.Status(), time.Since(start))
+			}()
+
+			handler.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// metricsMethod returns method if it is a standard HTTP method, or "other".
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "other"
+	}
+}
+
+func (m *Metrics) observe(flightKey, key metricsKey, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inFlight[flightKey]--
+
+	key.status = fmt.Sprintf("%dxx", status/100)
+	rm, ok := m.requests[key]
+	if !ok {
+		rm = &requestMetrics{buckets: make([]uint64, len(m.buckets))}
+		m.requests[key] = rm
+	}
+
+	seconds := duration.Seconds()
+	rm.count++
+	rm.sum += seconds
+	for i, upper := range m.buckets {
+		if seconds <= upper {
+			rm.buckets[i]++
+		}
+	}
+}
+
+// ServeHTTP writes the metrics in m using the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := w.Write([]byte(m.String())); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// String returns the metrics in m using the Prometheus text exposition format.
+func (m *Metrics) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requestKeys := make([]metricsKey, 0, len(m.requests))
+	for key := range m.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sortMetricsKeys(requestKeys)
+
+	flightKeys := make([]metricsKey, 0, len(m.inFlight))
+	for key := range m.inFlight {
+		flightKeys = append(flightKeys, key)
+	}
+	sortMetricsKeys(flightKeys)
+
+	var b strings.Builder
+	b.WriteString("# HELP http_requests_total Total number of HTTP requests.\n")
+	b.WriteString("# TYPE http_requests_total counter\n")
+	for _, key := range requestKeys {
+		fmt.Fprintf(&b, "http_requests_total%s %d\n", key.labels(""), m.requests[key].count)
+	}
+
+	b.WriteString("# HELP http_request_duration_seconds HTTP request latencies in seconds.\n")
+	b.WriteString("# TYPE http_request_duration_seconds histogram\n")
+	for _, key := range requestKeys {
+		rm := m.requests[key]
+		for i, upper := range m.buckets {
+			fmt.Fprintf(&b, "http_request_duration_seconds_bucket%s %d\n", key.labels(formatFloat(upper)), rm.buckets[i])
+		}
+
+		fmt.Fprintf(&b, "http_request_duration_seconds_bucket%s %d\n", key.labels("+Inf"), rm.count)
+		fmt.Fprintf(&b, "http_request_duration_seconds_sum%s %s\n", key.labels(""), formatFloat(rm.sum))
+		fmt.Fprintf(&b, "http_request_duration_seconds_count%s %d\n", key.labels(""), rm.count)
+	}
+
+	b.WriteString("# HELP http_requests_in_flight Number of HTTP requests currently being served.\n")
+	b.WriteString("# TYPE http_requests_in_flight gauge\n")
+	for _, key := range flightKeys {
+		fmt.Fprintf(&b, "http_requests_in_flight%s %d\n", key.labels(""), m.inFlight[key])
+	}
+
+	return b.String()
+}
+
+// labels formats k as a Prometheus label set, adding an "le" label if le is not empty.
+func (k metricsKey) labels(le string) string {
+	labels := []string{
+		fmt.Sprintf("method=\"%s\"", escapeLabelValue(k.method)),
+		fmt.Sprintf("route=\"%s\"", escapeLabelValue(k.route)),
+	}
+
+	if k.status != "" {
+		labels = append(labels, fmt.Sprintf("status=\"%s\"", k.status))
+	}
+
+	if le != "" {
+		labels = append(labels, fmt.Sprintf("le=\"%s\"", le))
+	}
+
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+func sortMetricsKeys(keys []metricsKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+
+		return keys[i].status < keys[j].status
+	})
+}
+
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,94 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	metrics := NewMetrics([]float64{1000, 0})
+	rm := RouteMap{
+		"/products/:productID": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if Segment(r.URL.Path, 1) == "missing" {
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}),
+		},
+		"/metrics": MethodHandlers{
+			http.MethodGet: metrics,
+		},
+	}
+
+	rm.ApplyMiddlewareIf(PatternFilter("/products/*"), MetricsMiddleware(metrics))
+	r := NewRouter(rm.VariableMatch())
+	r.ServeHTTP(httptest.NewRecorder(), NewRequest("GET", "/products/p1"))
+	r.ServeHTTP(httptest.NewRecorder(), NewRequest("GET", "/products/p2"))
+	r.ServeHTTP(httptest.NewRecorder(), NewRequest("GET", "/products/missing"))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, NewRequest("GET", "/metrics"))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	expected := []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",route="/products/:productID",status="2xx"} 2`,
+		`http_requests_total{method="GET",route="/products/:productID",status="4xx"} 1`,
+		"# TYPE http_request_duration_seconds histogram",
+		`http_request_duration_seconds_bucket{method="GET",route="/products/:productID",status="2xx",le="0"} 0`,
+		`http_request_duration_seconds_bucket{method="GET",route="/products/:productID",status="2xx",le="1000"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/products/:productID",status="2xx",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/products/:productID",status="2xx"} 2`,
+		"# TYPE http_requests_in_flight gauge",
+		`http_requests_in_flight{method="GET",route="/products/:productID"} 0`,
+	}
+
+	body := recorder.Body.String()
+	for _, line := range expected {
+		assert.Contains(t, body, line+"\n")
+	}
+
+	assert.Contains(t, body, `http_request_duration_seconds_sum{method="GET",route="/products/:productID",status="2xx"} `)
+	assert.NotContains(t, body, `route="/metrics"`)
+}
+
+func TestMetricsInFlight(t *testing.T) {
+	metrics := NewMetrics(nil)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, metrics.String(), `http_requests_in_flight{method="POST",route="unmatched"} 1`)
+	})
+
+	MetricsMiddleware(metrics)(handler).ServeHTTP(httptest.NewRecorder(), NewRequest("POST", "/"))
+	assert.Contains(t, metrics.String(), `http_requests_in_flight{method="POST",route="unmatched"} 0`)
+	assert.Equal(t, len(DefaultBuckets)+1, strings.Count(metrics.String(), "http_request_duration_seconds_bucket"))
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	assert.Equal(t, `a\\b\"c\nd`, escapeLabelValue("a\\b\"c\nd"))
+}
+
+func TestMetricsMiddlewareRouter(t *testing.T) {
+	metrics := NewMetrics(nil)
+	rm := RouteMap{
+		"/products/:productID": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		},
+	}
+
+	r := NewRouter(rm.VariableMatch())
+	r.Middleware = NewChain(MetricsMiddleware(metrics))
+	r.ServeHTTP(httptest.NewRecorder(), NewRequest("GET", "/products/p1"))
+	r.ServeHTTP(httptest.NewRecorder(), NewRequest("GET", "/missing"))
+	r.ServeHTTP(httptest.NewRecorder(), NewRequest("FOOBAR", "/missing"))
+
+	body := metrics.String()
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/products/:productID",status="2xx"} 1`+"\n")
+	assert.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="4xx"} 1`+"\n")
+	assert.Contains(t, body, `http_requests_total{method="other",route="unmatched",status="4xx"} 1`+"\n")
+	assert.NotContains(t, body, "FOOBAR")
+}
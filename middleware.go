@@ -1,7 +1,8 @@
 package router
 
 import (
-	"crypto/sha256"
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 )
@@ -24,16 +25,26 @@ func LoggingMiddleware() Middleware {
 // before the original handler is executed.
 // Otherwise, a 401 Status Unauthorized response is returned.
 func BasicAuthMiddleware(username, password string) Middleware {
-	sum := sha256.Sum256([]byte(username + password))
+	return BasicAuthProviderMiddleware("Restricted", StaticCredentials{username: password})
+}
+
+// BasicAuthProviderMiddleware returns a Middleware that requires requests' basic auth headers
+// to contain a username password combination accepted by provider
+// before the original handler is executed.
+// The authenticated username can be fetched using BasicAuthUsername.
+// Otherwise, a 401 Status Unauthorized response is returned for the specified realm.
+func BasicAuthProviderMiddleware(realm string, provider CredentialProvider) Middleware {
+	challenge := fmt.Sprintf("Basic realm=%q", realm)
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user, pass, ok := r.BasicAuth()
-			if ok && sha256.Sum256([]byte(user+pass)) == sum {
-				handler.ServeHTTP(w, r)
+			if ok && provider.Authenticate(user, pass) {
+				ctx := context.WithValue(r.Context(), basicAuthUsernameKey, user)
+				handler.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
 
-			w.Header().Set("WWW-Authenticate", "Basic realm=\"Restricted\"")
+			w.Header().Set("WWW-Authenticate", challenge)
 			w.WriteHeader(http.StatusUnauthorized)
 			if _, err := w.Write([]byte("401 Unauthorized\n")); err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -42,3 +53,9 @@ func BasicAuthMiddleware(username, password string) Middleware {
 		})
 	}
 }
+
+// BasicAuthUsername returns the username authenticated by BasicAuthProviderMiddleware.
+func BasicAuthUsername(r *http.Request) (string, bool) {
+	username, ok := r.Context().Value(basicAuthUsernameKey).(string)
+	return username, ok
+}
@@ -65,3 +65,57 @@ func TestBasicAuthMiddlewareInvalidAuth(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
 	assert.False(t, called)
 }
+
+func TestBasicAuthMiddlewareConcatenationCollision(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	r := &http.Request{Header: http.Header{}}
+	r.SetBasicAuth("ab", "c")
+
+	BasicAuthMiddleware("a", "bc")(handler).ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestBasicAuthProviderMiddleware(t *testing.T) {
+	var username string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, _ = BasicAuthUsername(r)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	provider := StaticCredentials{
+		"alice": "alicepass",
+		"bob":   "bobpass",
+	}
+
+	recorder := httptest.NewRecorder()
+	r := &http.Request{Header: http.Header{}}
+	r.SetBasicAuth("bob", "bobpass")
+
+	BasicAuthProviderMiddleware("Admin", provider)(handler).ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "bob", username)
+}
+
+func TestBasicAuthProviderMiddlewareInvalidAuth(t *testing.T) {
+	var called bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	provider := CredentialProviderFunc(func(username, password string) bool {
+		return false
+	})
+
+	recorder := httptest.NewRecorder()
+	r := &http.Request{Header: http.Header{}}
+	r.SetBasicAuth("alice", "alicepass")
+
+	BasicAuthProviderMiddleware("Admin", provider)(handler).ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, `Basic realm="Admin"`, recorder.Header().Get("WWW-Authenticate"))
+	assert.False(t, called)
+}
@@ -0,0 +1,181 @@
+package router
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A RateLimitKeyFunc returns the key a request is rate limited by,
+// such as the client's IP address or API key.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitByIP is a RateLimitKeyFunc that limits requests by the client's IP address.
+// Use RealIPMiddleware before the rate limit when running behind proxies.
+func RateLimitByIP(r *http.Request) string {
+	return ClientIP(r)
+}
+
+// RateLimitByHeader returns a RateLimitKeyFunc that limits requests by the value of the specified header.
+// Requests without the header are limited by the client's IP address,
+// so that they do not all share a single limit.
+func RateLimitByHeader(header string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if value := r.Header.Get(header); value != "" {
+			return "header:" + value
+		}
+
+		return "ip:" + ClientIP(r)
+	}
+}
+
+// RateLimitByRoute returns a RateLimitKeyFunc that limits requests by key separately for each route,
+// by prefixing the key with the matched route's method and pattern, such as "GET /products/:productID".
+// The matched route is required, so the rate limit must be applied using RouteMap.ApplyMiddleware
+// or Router.MatchMiddleware.
+func RateLimitByRoute(key RateLimitKeyFunc) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if route, ok := MatchedRoute(r); ok {
+			return route.Method + " " + route.Pattern + " " + key(r)
+		}
+
+		return key(r)
+	}
+}
+
+// RateLimitStatus describes the state of a key's quota after a request has been counted.
+type RateLimitStatus struct {
+	// Allowed is true if the request is within the limit.
+	Allowed bool
+	// Limit is the maximum number of requests allowed per window.
+	Limit int
+	// Remaining is the number of requests that can still be made.
+	Remaining int
+	// Reset is the time until the quota is fully restored.
+	Reset time.Duration
+	// RetryAfter is the time until the next request will be allowed.
+	// It is zero if Allowed is true.
+	RetryAfter time.Duration
+}
+
+// A RateLimitStore counts requests for each key.
+// Implementations backed by external systems can be used to share limits between processes.
+type RateLimitStore interface {
+	Take(key string, limit int, window time.Duration) (RateLimitStatus, error)
+}
+
+// RateLimitMiddleware returns a Middleware that allows at most limit requests per window for each key.
+// Every response includes RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+// Once a key's limit is reached, a 429 Too Many Requests response with a Retry-After header is returned.
+// Keys are shared by every handler the Middleware is applied to, and by every Middleware using the same store,
+// so use RateLimitByRoute to give each route its own limit.
+// RateLimitMiddleware panics if limit or window is not positive.
+func RateLimitMiddleware(limit int, window time.Duration, key RateLimitKeyFunc, store RateLimitStore) Middleware {
+	if limit <= 0 || window <= 0 {
+		panic("router: rate limit and window must be positive")
+	}
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status, err := store.Take(key(r), limit, window)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(status.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(status.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(status.Reset)))
+			if status.Allowed {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Retry-After", strconv.Itoa(seconds(status.RetryAfter)))
+			http.Error(w, "429 Too Many Requests", http.StatusTooManyRequests)
+		})
+	}
+}
+
+// seconds returns d in whole seconds, rounded up.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// MemoryRateLimitStore is a RateLimitStore that keeps a token bucket for each key in memory.
+// Each bucket holds up to limit tokens and is refilled at a rate of limit tokens per window.
+// Buckets that have been idle long enough to refill completely are evicted.
+type MemoryRateLimitStore struct {
+	now       func() time.Time
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	window  time.Duration
+}
+
+// NewMemoryRateLimitStore returns an empty MemoryRateLimitStore.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		now:     time.Now,
+		buckets: map[string]*tokenBucket{},
+	}
+}
+
+// Take removes a token from key's bucket if one is available.
+func (s *MemoryRateLimitStore) Take(key string, limit int, window time.Duration) (RateLimitStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now, window)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit)}
+		s.buckets[key] = bucket
+	} else {
+		bucket.tokens = math.Min(float64(limit), bucket.tokens+float64(limit)*float64(now.Sub(bucket.updated))/float64(window))
+	}
+
+	bucket.updated = now
+	bucket.window = window
+
+	status := RateLimitStatus{Limit: limit}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		status.Allowed = true
+	} else {
+		status.RetryAfter = refillTime(1-bucket.tokens, limit, window)
+	}
+
+	status.Remaining = int(bucket.tokens)
+	status.Reset = refillTime(float64(limit)-bucket.tokens, limit, window)
+	return status, nil
+}
+
+// refillTime returns the time it takes to refill the specified number of tokens.
+func refillTime(tokens float64, limit int, window time.Duration) time.Duration {
+	return time.Duration(math.Ceil(tokens * float64(window) / float64(limit)))
+}
+
+// sweep evicts full buckets, at most once per window.
+func (s *MemoryRateLimitStore) sweep(now time.Time, window time.Duration) {
+	if now.Sub(s.lastSweep) < window {
+		return
+	}
+
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updated) >= bucket.window {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.lastSweep = now
+}
@@ -0,0 +1,148 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	var calls int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+
+	middleware := RateLimitMiddleware(2, time.Minute, RateLimitByIP, NewMemoryRateLimitStore())(handler)
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		r := NewRequest("GET", "/")
+		r.RemoteAddr = "10.0.0.1:5000"
+
+		middleware.ServeHTTP(recorder, r)
+		assert.Equal(t, "2", recorder.Header().Get("RateLimit-Limit"))
+
+		switch i {
+		case 0:
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "1", recorder.Header().Get("RateLimit-Remaining"))
+			assert.Equal(t, "30", recorder.Header().Get("RateLimit-Reset"))
+		case 1:
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+		case 2:
+			assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			assert.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+			assert.Equal(t, "30", recorder.Header().Get("Retry-After"))
+		}
+	}
+
+	assert.Equal(t, 2, calls)
+
+	recorder := httptest.NewRecorder()
+	r := NewRequest("GET", "/")
+	r.RemoteAddr = "10.0.0.2:5000"
+	middleware.ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+type errRateLimitStore struct{}
+
+func (errRateLimitStore) Take(string, int, time.Duration) (RateLimitStatus, error) {
+	return RateLimitStatus{}, errors.New("unavailable")
+}
+
+func TestRateLimitMiddlewareStoreError(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not be called")
+	})
+
+	recorder := httptest.NewRecorder()
+	RateLimitMiddleware(1, time.Second, RateLimitByHeader("X-API-Key"), errRateLimitStore{})(handler).ServeHTTP(recorder, NewRequest("GET", "/"))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+
+	status, _ := store.Take("key", 1, time.Second)
+	assert.True(t, status.Allowed)
+
+	status, _ = store.Take("key", 1, time.Second)
+	assert.False(t, status.Allowed)
+	assert.Equal(t, time.Second, status.RetryAfter)
+
+	now = now.Add(time.Second / 2)
+	status, _ = store.Take("key", 1, time.Second)
+	assert.False(t, status.Allowed)
+	assert.Equal(t, time.Second/2, status.RetryAfter)
+
+	now = now.Add(time.Second / 2)
+	status, _ = store.Take("key", 1, time.Second)
+	assert.True(t, status.Allowed)
+}
+
+func TestMemoryRateLimitStoreEviction(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+
+	store.Take("a", 1, time.Second)
+	store.Take("b", 1, time.Second)
+	assert.Len(t, store.buckets, 2)
+
+	now = now.Add(time.Second)
+	store.Take("c", 1, time.Second)
+	assert.Len(t, store.buckets, 1)
+}
+
+func TestRateLimitByRoute(t *testing.T) {
+	rm := RouteMap{
+		"/products": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		},
+		"/orders": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		},
+	}
+
+	rm.ApplyMiddleware(RateLimitMiddleware(1, time.Minute, RateLimitByRoute(RateLimitByIP), NewMemoryRateLimitStore()))
+	router := NewRouter(rm.StringMatch())
+
+	cases := []struct {
+		Path     string
+		Expected int
+	}{
+		{Path: "/products", Expected: http.StatusOK},
+		{Path: "/orders", Expected: http.StatusOK},
+		{Path: "/products", Expected: http.StatusTooManyRequests},
+	}
+
+	for _, c := range cases {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, NewRequest("GET", c.Path))
+		assert.Equal(t, c.Expected, recorder.Code, c.Path)
+	}
+}
+
+func TestRateLimitMiddlewareInvalidLimit(t *testing.T) {
+	assert.Panics(t, func() { RateLimitMiddleware(0, time.Minute, RateLimitByIP, NewMemoryRateLimitStore()) })
+	assert.Panics(t, func() { RateLimitMiddleware(1, 0, RateLimitByIP, NewMemoryRateLimitStore()) })
+}
+
+func TestRateLimitByHeader(t *testing.T) {
+	key := RateLimitByHeader("X-API-Key")
+
+	r := NewRequest("GET", "/")
+	r.Header = http.Header{}
+	r.RemoteAddr = "10.0.0.1:5000"
+	assert.Equal(t, "ip:10.0.0.1", key(r))
+
+	r.Header.Set("X-API-Key", "k1")
+	assert.Equal(t, "header:k1", key(r))
+}
@@ -0,0 +1,159 @@
+package router
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ParseCIDRs parses each CIDR, such as "10.0.0.0/8" or "2001:db8::/32".
+// Single IP addresses, such as "10.0.0.1", are treated as /32 or /128 networks.
+func ParseCIDRs(cidrs ...string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: cidr}
+			}
+
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// RealIPMiddleware returns a Middleware that determines the real IP address of the client
+// from header, which must be the header set by the trusted proxies,
+// such as "Forwarded", "X-Forwarded-For" or "X-Real-IP".
+// Other forwarding headers are ignored, since proxies usually pass them through from the client unchanged.
+// The header is only used if the request was sent by one of the trusted proxies.
+// The addresses in the header are walked from right to left,
+// and the first address that is not a trusted proxy is the client's.
+// The client's IP address can be fetched using ClientIP.
+func RealIPMiddleware(header string, trustedProxies []*net.IPNet) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := realIP(r, header, trustedProxies)
+			ctx := context.WithValue(r.Context(), clientIPKey, ip)
+			handler.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIP returns the client's IP address determined by RealIPMiddleware.
+// If RealIPMiddleware was not used, the host of r.RemoteAddr is returned.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey).(string); ok {
+		return ip
+	}
+
+	return remoteHost(r)
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func realIP(r *http.Request, header string, trustedProxies []*net.IPNet) string {
+	client := remoteHost(r)
+	if !ipInNetworks(net.ParseIP(client), trustedProxies) {
+		return client
+	}
+
+	var hops []string
+	if http.CanonicalHeaderKey(header) == "Forwarded" {
+		hops = forwardedFor(r.Header)
+	} else {
+		hops = splitHeaderList(r.Header.Values(header))
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			// obfuscated or malformed addresses cannot be trusted past
+			break
+		}
+
+		client = ip.String()
+		if !ipInNetworks(ip, trustedProxies) {
+			break
+		}
+	}
+
+	return client
+}
+
+// forwardedFor returns the "for" addresses in the Forwarded headers of h, as described by RFC 7239.
+func forwardedFor(h http.Header) []string {
+	addrs := []string{}
+	for _, element := range splitHeaderList(h["Forwarded"]) {
+		for _, pair := range strings.Split(element, ";") {
+			pair = strings.TrimSpace(pair)
+			if len(pair) < 4 || !strings.EqualFold(pair[:4], "for=") {
+				continue
+			}
+
+			addr := strings.Trim(pair[4:], `"`)
+			if strings.HasPrefix(addr, "[") {
+				if end := strings.Index(addr, "]"); end > 0 {
+					addr = addr[1:end]
+				}
+			} else if host, _, err := net.SplitHostPort(addr); err == nil {
+				addr = host
+			}
+
+			addrs = append(addrs, addr)
+		}
+	}
+
+	return addrs
+}
+
+// splitHeaderList splits comma-separated header values.
+func splitHeaderList(values []string) []string {
+	items := []string{}
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+
+	return items
+}
+
+func ipInNetworks(ip net.IP, networks []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
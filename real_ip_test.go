@@ -0,0 +1,134 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	networks, err := ParseCIDRs("10.0.0.0/8", "192.168.1.1", "2001:db8::/32", "::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "10.0.0.0/8", networks[0].String())
+	assert.Equal(t, "192.168.1.1/32", networks[1].String())
+	assert.Equal(t, "2001:db8::/32", networks[2].String())
+	assert.Equal(t, "::1/128", networks[3].String())
+
+	_, err = ParseCIDRs("10.0.0.0/33")
+	assert.Error(t, err)
+
+	_, err = ParseCIDRs("not an ip")
+	assert.Error(t, err)
+}
+
+func TestRealIPMiddleware(t *testing.T) {
+	trusted, err := ParseCIDRs("10.0.0.0/8", "2001:db8::/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		Header     string
+		RemoteAddr string
+		Headers    map[string]string
+		Expected   string
+	}{
+		"Untrusted remote": {
+			Header:     "X-Forwarded-For",
+			RemoteAddr: "203.0.113.1:1234",
+			Headers:    map[string]string{"X-Forwarded-For": "1.1.1.1"},
+			Expected:   "203.0.113.1",
+		},
+		"No headers": {
+			Header:     "X-Forwarded-For",
+			RemoteAddr: "10.0.0.1:1234",
+			Expected:   "10.0.0.1",
+		},
+		"X-Forwarded-For": {
+			Header:     "X-Forwarded-For",
+			RemoteAddr: "10.0.0.1:1234",
+			Headers:    map[string]string{"X-Forwarded-For": "1.1.1.1, 203.0.113.5, 10.0.0.2"},
+			Expected:   "203.0.113.5",
+		},
+		"X-Forwarded-For all trusted": {
+			Header:     "X-Forwarded-For",
+			RemoteAddr: "10.0.0.1:1234",
+			Headers:    map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"},
+			Expected:   "10.0.0.3",
+		},
+		"X-Real-IP": {
+			Header:     "X-Real-IP",
+			RemoteAddr: "10.0.0.1:1234",
+			Headers:    map[string]string{"X-Real-IP": "203.0.113.5"},
+			Expected:   "203.0.113.5",
+		},
+		"Forwarded": {
+			Header:     "Forwarded",
+			RemoteAddr: "[2001:db8::1]:1234",
+			Headers: map[string]string{
+				"Forwarded":       `for=192.0.2.43, for="[2001:db8:cafe::17]:4711";proto=https`,
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			Expected: "192.0.2.43",
+		},
+		"Spoofed Forwarded": {
+			Header:     "X-Forwarded-For",
+			RemoteAddr: "10.0.0.1:1234",
+			Headers: map[string]string{
+				"Forwarded":       "for=192.168.1.1",
+				"X-Forwarded-For": "203.0.113.9",
+			},
+			Expected: "203.0.113.9",
+		},
+		"Spoofed X-Forwarded-For": {
+			Header:     "X-Real-IP",
+			RemoteAddr: "10.0.0.1:1234",
+			Headers: map[string]string{
+				"X-Forwarded-For": "192.168.1.1",
+				"X-Real-IP":       "203.0.113.9",
+			},
+			Expected: "203.0.113.9",
+		},
+		"Forwarded IPv6 client": {
+			Header:     "Forwarded",
+			RemoteAddr: "10.0.0.1:1234",
+			Headers:    map[string]string{"Forwarded": `for="[2001:db9::17]:4711"`},
+			Expected:   "2001:db9::17",
+		},
+		"Forwarded obfuscated": {
+			Header:     "Forwarded",
+			RemoteAddr: "10.0.0.1:1234",
+			Headers:    map[string]string{"Forwarded": "for=_hidden, for=10.0.0.2"},
+			Expected:   "10.0.0.2",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var ip string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ip = ClientIP(r)
+			})
+
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = c.RemoteAddr
+			for key, value := range c.Headers {
+				r.Header.Set(key, value)
+			}
+
+			RealIPMiddleware(c.Header, trusted)(handler).ServeHTTP(httptest.NewRecorder(), r)
+			assert.Equal(t, c.Expected, ip)
+		})
+	}
+}
+
+func TestClientIPWithoutMiddleware(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.1:1234"
+	assert.Equal(t, "203.0.113.1", ClientIP(r))
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	glob "github.com/ryanuber/go-glob"
+)
+
+// RedirectConfig configures RedirectMiddleware.
+type RedirectConfig struct {
+	// HTTPS redirects requests that were not made over HTTPS.
+	HTTPS bool
+	// CanonicalHost, if set, redirects requests for any other host to it, such as "example.com".
+	CanonicalHost string
+	// TrustedProxies are the proxies whose X-Forwarded-Proto header is used to determine the request's scheme.
+	// Requests from other addresses are only considered HTTPS if they were made over TLS.
+	TrustedProxies []*net.IPNet
+	// ExemptPaths are glob patterns for request paths that are never redirected, such as "/health".
+	ExemptPaths []string
+}
+
+// RedirectMiddleware returns a Middleware that redirects requests to HTTPS and/or the canonical host,
+// preserving their path and query.
+// GET and HEAD requests are redirected with a 301 Moved Permanently response,
+// and other methods with a 308 Permanent Redirect response so their method and body are preserved.
+// Since it should run before matching, RedirectMiddleware is typically used as Router.Middleware.
+func RedirectMiddleware(config RedirectConfig) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, pattern := range config.ExemptPaths {
+				if glob.Glob(pattern, r.URL.Path) {
+					handler.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			scheme := requestScheme(r, config.TrustedProxies)
+			host := r.Host
+			redirect := false
+
+			if config.CanonicalHost != "" && !strings.EqualFold(host, config.CanonicalHost) {
+				host = config.CanonicalHost
+				redirect = true
+			}
+
+			if config.HTTPS && scheme != "https" {
+				scheme = "https"
+				if h, _, err := net.SplitHostPort(host); err == nil {
+					host = h
+				}
+
+				redirect = true
+			}
+
+			if !redirect {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			status := http.StatusPermanentRedirect
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				status = http.StatusMovedPermanently
+			}
+
+			http.Redirect(w, r, scheme+"://"+host+r.URL.RequestURI(), status)
+		})
+	}
+}
+
+// requestScheme returns the scheme r was made with.
+// The X-Forwarded-Proto header is only used if r was sent by one of trustedProxies,
+// in which case its rightmost value, which was set by that proxy, is used.
+// Values to its left may have been supplied by the client.
+func requestScheme(r *http.Request, trustedProxies []*net.IPNet) string {
+	if r.TLS != nil {
+		return "https"
+	}
+
+	if !ipInNetworks(net.ParseIP(remoteHost(r)), trustedProxies) {
+		return "http"
+	}
+
+	if protos := splitHeaderList(r.Header.Values("X-Forwarded-Proto")); len(protos) > 0 {
+		return strings.ToLower(protos[len(protos)-1])
+	}
+
+	return "http"
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectMiddleware(t *testing.T) {
+	trusted, err := ParseCIDRs("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := RedirectConfig{
+		HTTPS:          true,
+		CanonicalHost:  "example.com",
+		TrustedProxies: trusted,
+		ExemptPaths:    []string{"/health"},
+	}
+
+	cases := map[string]struct {
+		Method     string
+		URL        string
+		RemoteAddr string
+		Proto      string
+		Status     int
+		Location   string
+	}{
+		"HTTP to HTTPS": {
+			Method:   "GET",
+			URL:      "http://example.com/products?page=2",
+			Status:   http.StatusMovedPermanently,
+			Location: "https://example.com/products?page=2",
+		},
+		"Canonical host": {
+			Method:   "GET",
+			URL:      "https://www.example.com/products",
+			Status:   http.StatusMovedPermanently,
+			Location: "https://example.com/products",
+		},
+		"POST uses 308": {
+			Method:   "POST",
+			URL:      "http://www.example.com/products",
+			Status:   http.StatusPermanentRedirect,
+			Location: "https://example.com/products",
+		},
+		"Already canonical": {
+			Method: "GET",
+			URL:    "https://example.com/products",
+			Status: http.StatusOK,
+		},
+		"Trusted X-Forwarded-Proto": {
+			Method:     "GET",
+			URL:        "http://example.com/products",
+			RemoteAddr: "10.0.0.1:1234",
+			Proto:      "https",
+			Status:     http.StatusOK,
+		},
+		"Client supplied X-Forwarded-Proto": {
+			Method:     "GET",
+			URL:        "http://example.com/products",
+			RemoteAddr: "10.0.0.1:1234",
+			Proto:      "https, http",
+			Status:     http.StatusMovedPermanently,
+			Location:   "https://example.com/products",
+		},
+		"Untrusted X-Forwarded-Proto": {
+			Method:     "GET",
+			URL:        "http://example.com/products",
+			RemoteAddr: "203.0.113.1:1234",
+			Proto:      "https",
+			Status:     http.StatusMovedPermanently,
+			Location:   "https://example.com/products",
+		},
+		"Exempt path": {
+			Method: "GET",
+			URL:    "http://10.0.0.5:8080/health",
+			Status: http.StatusOK,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+			r := httptest.NewRequest(c.Method, c.URL, nil)
+			if c.RemoteAddr != "" {
+				r.RemoteAddr = c.RemoteAddr
+			}
+
+			if c.Proto != "" {
+				r.Header.Set("X-Forwarded-Proto", c.Proto)
+			}
+
+			recorder := httptest.NewRecorder()
+			RedirectMiddleware(config)(handler).ServeHTTP(recorder, r)
+			assert.Equal(t, c.Status, recorder.Code)
+			assert.Equal(t, c.Location, recorder.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectMiddlewareHTTPSOnly(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	recorder := httptest.NewRecorder()
+	RedirectMiddleware(RedirectConfig{HTTPS: true})(handler).ServeHTTP(recorder, httptest.NewRequest("GET", "http://localhost:8080/a?b=c", nil))
+	assert.Equal(t, http.StatusMovedPermanently, recorder.Code)
+	assert.Equal(t, "https://localhost/a?b=c", recorder.Header().Get("Location"))
+}
@@ -0,0 +1,56 @@
+package router
+
+import "net/http"
+
+// statusWriter records the status code and number of bytes written to a http.ResponseWriter.
+type statusWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func newStatusWriter(w http.ResponseWriter) *statusWriter {
+	return &statusWriter{ResponseWriter: w}
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.setStatus(status)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.setStatus(http.StatusOK)
+
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// Status returns the status code written, or 200 if the handler did not write one.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+
+	return w.status
+}
+
+// setStatus records status if no status has been recorded yet,
+// without writing it to the underlying http.ResponseWriter.
+func (w *statusWriter) setStatus(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Flush implements http.Flusher if the underlying http.ResponseWriter does.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use with http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
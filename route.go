@@ -0,0 +1,87 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Route describes the route a request was matched to.
+type Route struct {
+	// Method is the http method of the route.
+	Method string
+	// Pattern is the pattern used to match the request's path, such as "/products/:productID".
+	Pattern string
+	// Kind is the kind of HandlerMatcher used: "glob", "regex", "string" or "variable".
+	Kind string
+}
+
+// MatchedRoute returns the route r was matched to.
+// Routes are recorded by the handlers returned from the HandlerMatcher constructors in this package,
+// and by Router before its MatchMiddleware runs.
+func MatchedRoute(r *http.Request) (Route, bool) {
+	route, ok := r.Context().Value(matchedRouteKey).(Route)
+	return route, ok
+}
+
+// routeHandler records its route in the request's context before calling handler.
+type routeHandler struct {
+	route   Route
+	handler http.Handler
+	// match caches the handler wrapped by Router.MatchMiddleware.
+	match chainCache
+}
+
+func newRouteHandler(kind, method, pattern string, handler http.Handler) *routeHandler {
+	return &routeHandler{
+		route:   Route{Method: method, Pattern: pattern, Kind: kind},
+		handler: handler,
+	}
+}
+
+func (h *routeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(w, withRoute(r, h.route))
+}
+
+// withRoute returns r with route recorded in its context.
+func withRoute(r *http.Request, route Route) *http.Request {
+	if recorder, ok := r.Context().Value(routeRecorderKey).(*routeRecorder); ok {
+		recorder.record(route)
+	}
+
+	if existing, ok := MatchedRoute(r); ok && existing == route {
+		return r
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), matchedRouteKey, route))
+}
+
+// routeRecorder allows middleware that runs before matching
+// to learn which route was matched once the request has been served.
+type routeRecorder struct {
+	mu    sync.Mutex
+	route Route
+	ok    bool
+}
+
+// recordRoute returns r with a new routeRecorder in its context.
+func recordRoute(r *http.Request) (*http.Request, *routeRecorder) {
+	recorder := &routeRecorder{}
+	return r.WithContext(context.WithValue(r.Context(), routeRecorderKey, recorder)), recorder
+}
+
+func (rr *routeRecorder) record(route Route) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.route = route
+	rr.ok = true
+}
+
+// Route returns the route that was matched, if any.
+func (rr *routeRecorder) Route() (Route, bool) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	return rr.route, rr.ok
+}
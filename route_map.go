@@ -1,6 +1,10 @@
 package router
 
-import "net/http"
+import (
+	"net/http"
+
+	glob "github.com/ryanuber/go-glob"
+)
 
 // MethodHandlers map http methods to http.Handlers.
 type MethodHandlers map[string]http.Handler
@@ -8,13 +12,60 @@ type MethodHandlers map[string]http.Handler
 // A RouteMap maps url path patterns to MethodHandlers.
 type RouteMap map[string]MethodHandlers
 
-// ApplyMiddlware applies each middleware to each http.Handler in rm.
+// ApplyMiddleware applies each middleware to each http.Handler in mh.
+// Middleware are applied in order, so middleware[0] wraps the handler first and is the innermost,
+// and the last middleware is the outermost and runs first.
+// Middleware applied by a later call wraps middleware applied by earlier calls.
+func (mh MethodHandlers) ApplyMiddleware(middleware ...Middleware) {
+	for method, handler := range mh {
+		for _, middleware := range middleware {
+			handler = middleware(handler)
+		}
+
+		mh[method] = handler
+	}
+}
+
+// ApplyMiddleware applies each middleware to each http.Handler in rm.
+// The order of wrapping is the same as MethodHandlers.ApplyMiddleware.
+// Since middleware applied by a later call is the outermost,
+// middleware attached to MethodHandlers when building rm runs after middleware applied to rm.
 func (rm RouteMap) ApplyMiddleware(middleware ...Middleware) {
-	for path, methodHandlers := range rm {
-		for method, _ := range methodHandlers {
+	rm.ApplyMiddlewareIf(func(pattern, method string) bool { return true }, middleware...)
+}
+
+// A RouteFilter reports whether a route should be selected.
+type RouteFilter func(pattern, method string) bool
+
+// MethodFilter returns a RouteFilter that selects routes with any of the specified methods.
+func MethodFilter(methods ...string) RouteFilter {
+	return func(pattern, method string) bool {
+		return containsString(methods, method)
+	}
+}
+
+// PatternFilter returns a RouteFilter that selects routes whose pattern glob matches g.
+// For example, PatternFilter("/admin/*") selects "/admin/users" and "/admin/users/:userID".
+func PatternFilter(g string) RouteFilter {
+	return func(pattern, method string) bool {
+		return glob.Glob(g, pattern)
+	}
+}
+
+// ApplyMiddlewareIf applies each middleware to each http.Handler in rm selected by filter.
+// The order of wrapping is the same as RouteMap.ApplyMiddleware.
+func (rm RouteMap) ApplyMiddlewareIf(filter RouteFilter, middleware ...Middleware) {
+	for pattern, methodHandlers := range rm {
+		for method, handler := range methodHandlers {
+			if !filter(pattern, method) {
+				continue
+			}
+
 			for _, middleware := range middleware {
-				rm[path][method] = middleware(rm[path][method])
+				handler = middleware(handler)
 			}
+
+			methodHandlers[method] = handler
 		}
 	}
 }
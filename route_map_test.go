@@ -57,3 +57,62 @@ func TestRouteMapApplyMiddlware(t *testing.T) {
 
 	assert.Equal(t, 5, calls)
 }
+
+func TestMethodHandlersApplyMiddlewareOrder(t *testing.T) {
+	var order []string
+	record := func(name string) Middleware {
+		return func(handler http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				handler.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	mh := MethodHandlers{
+		http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "handler")
+		}),
+	}
+
+	mh.ApplyMiddleware(record("first"), record("second"))
+	rm := RouteMap{"/products": mh}
+	rm.ApplyMiddleware(record("route map"))
+
+	rm["/products"][http.MethodGet].ServeHTTP(nil, nil)
+	assert.Equal(t, []string{"route map", "second", "first", "handler"}, order)
+}
+
+func TestRouteMapApplyMiddlewareIf(t *testing.T) {
+	rm := RouteMap{
+		"/products": MethodHandlers{
+			http.MethodGet:  nil,
+			http.MethodPost: nil,
+		},
+		"/admin/users": MethodHandlers{
+			http.MethodGet:    nil,
+			http.MethodDelete: nil,
+		},
+		"/admin/users/:userID": MethodHandlers{
+			http.MethodDelete: nil,
+		},
+	}
+
+	wrapped := map[string]int{}
+	middleware := func(name string) Middleware {
+		return func(handler http.Handler) http.Handler {
+			wrapped[name]++
+			return handler
+		}
+	}
+
+	rm.ApplyMiddlewareIf(MethodFilter(http.MethodDelete, http.MethodPost), middleware("method"))
+	rm.ApplyMiddlewareIf(PatternFilter("/admin/*"), middleware("pattern"))
+	rm.ApplyMiddlewareIf(func(pattern, method string) bool {
+		return pattern == "/products" && method == http.MethodGet
+	}, middleware("predicate"))
+
+	assert.Equal(t, 3, wrapped["method"])
+	assert.Equal(t, 3, wrapped["pattern"])
+	assert.Equal(t, 1, wrapped["predicate"])
+}
@@ -0,0 +1,86 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchedRoute(t *testing.T) {
+	var route Route
+	var found bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, found = MatchedRoute(r)
+	})
+
+	cases := map[string]struct {
+		Matcher  HandlerMatcher
+		Request  *http.Request
+		Expected Route
+	}{
+		"Glob": {
+			Matcher:  NewGlobHandlerMatcher("GET", "/products/*", handler),
+			Request:  NewRequest("GET", "/products/p123"),
+			Expected: Route{Method: "GET", Pattern: "/products/*", Kind: "glob"},
+		},
+		"Regex": {
+			Matcher:  NewRegexHandlerMatcher("GET", "/products/.+", handler),
+			Request:  NewRequest("GET", "/products/p123"),
+			Expected: Route{Method: "GET", Pattern: "/products/.+", Kind: "regex"},
+		},
+		"String": {
+			Matcher:  NewStringHandlerMatcher("GET", "/products", handler),
+			Request:  NewRequest("GET", "/products"),
+			Expected: Route{Method: "GET", Pattern: "/products", Kind: "string"},
+		},
+		"Variable": {
+			Matcher:  NewVariableHandlerMatcher("GET", "/products/:productID", handler),
+			Request:  NewRequest("GET", "/products/p123"),
+			Expected: Route{Method: "GET", Pattern: "/products/:productID", Kind: "variable"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			route, found = Route{}, false
+			h, ok := c.Matcher(c.Request)
+			assert.True(t, ok)
+
+			h.ServeHTTP(httptest.NewRecorder(), c.Request)
+			assert.True(t, found)
+			assert.Equal(t, c.Expected, route)
+		})
+	}
+}
+
+func TestMatchedRouteRouter(t *testing.T) {
+	var routes []Route
+	record := func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, _ := MatchedRoute(r)
+			routes = append(routes, route)
+			handler.ServeHTTP(w, r)
+		})
+	}
+
+	rm := RouteMap{
+		"/products/:productID": MethodHandlers{
+			http.MethodDelete: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		},
+	}
+
+	rm.ApplyMiddleware(record)
+	r := NewRouter(rm.VariableMatch())
+	r.MatchMiddleware = NewChain(record)
+	r.ServeHTTP(httptest.NewRecorder(), NewRequest("DELETE", "/products/p123"))
+
+	expected := Route{Method: "DELETE", Pattern: "/products/:productID", Kind: "variable"}
+	assert.Equal(t, []Route{expected, expected}, routes)
+}
+
+func TestMatchedRouteNotFound(t *testing.T) {
+	_, ok := MatchedRoute(NewRequest("GET", "/"))
+	assert.False(t, ok)
+}
@@ -2,12 +2,41 @@ package router
 
 import (
 	"net/http"
+	"sync"
+	"sync/atomic"
 )
 
 // Router is the root handler for an application.
 type Router struct {
+	// Matchers are attempted in order before any route groups.
+	// They must not be modified while the Router is serving requests;
+	// use SetRoutes and RemoveRoutes to change routes at runtime.
 	Matchers []HandlerMatcher
 	NotFound func(http.ResponseWriter, *http.Request)
+	// Middleware wraps the entire dispatch of each request, before a match is attempted.
+	// It runs for every request, including those handled by NotFound.
+	// The dispatch is wrapped once, and wrapped again only when Middleware is assigned a different Chain.
+	// Changes are detected by the Chain's length and backing array, so modifying its elements in place,
+	// such as o.Middleware[0] = m, is not detected and the old middleware keeps being used;
+	// assign a new Chain, such as one returned by NewChain or Chain.Append, instead.
+	Middleware Chain
+	// MatchMiddleware wraps the handler selected by Matchers, after a match is found.
+	// It runs inside Middleware, and does not run for requests handled by NotFound.
+	// The selected route can be fetched using MatchedRoute.
+	// Handlers created by this package's HandlerMatcher constructors and RouteMap methods are wrapped once,
+	// and wrapped again only when MatchMiddleware is assigned a different Chain, as with Middleware.
+	// Handlers returned by other HandlerMatchers are wrapped on every request.
+	MatchMiddleware Chain
+
+	mu         sync.Mutex
+	groups     atomic.Value
+	middleware chainCache
+}
+
+// routeGroups is an immutable snapshot of a Router's named route groups.
+type routeGroups struct {
+	names    []string
+	matchers map[string][]HandlerMatcher
 }
 
 // NewRouter returns an initialized Router with the specified matchers.
@@ -20,14 +49,125 @@ func NewRouter(matchers []HandlerMatcher) *Router {
 
 // ServeHTTP attempts to match r to a http.Handler using o.Matchers.
 // If no match is found, o.NotFound is executed.
+// The dispatch is wrapped by o.Middleware, and matched handlers are wrapped by o.MatchMiddleware.
 func (o *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	for _, match := range o.Matchers {
+	if len(o.Middleware) == 0 {
+		o.dispatch(w, r)
+		return
+	}
+
+	o.middleware.then(o.Middleware, (*dispatcher)(o)).ServeHTTP(w, r)
+}
+
+// dispatcher is the http.Handler wrapped by Router.Middleware.
+type dispatcher Router
+
+func (d *dispatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*Router)(d).dispatch(w, r)
+}
+
+func (o *Router) dispatch(w http.ResponseWriter, r *http.Request) {
+	if o.serveMatch(o.Matchers, w, r) {
+		return
+	}
+
+	if groups := o.loadGroups(); groups != nil {
+		for _, name := range groups.names {
+			if o.serveMatch(groups.matchers[name], w, r) {
+				return
+			}
+		}
+	}
+
+	o.NotFound(w, r)
+}
+
+func (o *Router) serveMatch(matchers []HandlerMatcher, w http.ResponseWriter, r *http.Request) bool {
+	for _, match := range matchers {
 		handler, ok := match(r)
 		if ok {
+			if rh, ok := handler.(*routeHandler); ok {
+				r = withRoute(r, rh.route)
+				handler = rh.match.then(o.MatchMiddleware, rh)
+			} else {
+				handler = o.MatchMiddleware.Then(handler)
+			}
+
 			handler.ServeHTTP(w, r)
-			return
+			return true
 		}
 	}
 
-	o.NotFound(w, r)
+	return false
+}
+
+// SetRoutes adds a group of matchers to o with the specified name,
+// or replaces the matchers of the group if it already exists.
+// Groups are attempted after o.Matchers, in the order they were first added.
+// SetRoutes is safe to call while o is serving requests:
+// the groups are copied and swapped atomically, so each request sees either
+// the old or the new set of groups, never a mix.
+// For example, a tenant's routes can be added using o.SetRoutes("tenant-a", rm.VariableMatch()).
+func (o *Router) SetRoutes(name string, matchers []HandlerMatcher) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	current := o.loadGroups()
+	next := &routeGroups{matchers: map[string][]HandlerMatcher{}}
+	if current != nil {
+		next.names = append(next.names, current.names...)
+		for key, value := range current.matchers {
+			next.matchers[key] = value
+		}
+	}
+
+	if _, ok := next.matchers[name]; !ok {
+		next.names = append(next.names, name)
+	}
+
+	next.matchers[name] = append([]HandlerMatcher{}, matchers...)
+	o.groups.Store(next)
+}
+
+// RemoveRoutes removes the group of matchers with the specified name from o.
+// It returns false if the group does not exist.
+// Like SetRoutes, it is safe to call while o is serving requests.
+func (o *Router) RemoveRoutes(name string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	current := o.loadGroups()
+	if current == nil {
+		return false
+	}
+
+	if _, ok := current.matchers[name]; !ok {
+		return false
+	}
+
+	next := &routeGroups{matchers: map[string][]HandlerMatcher{}}
+	for _, key := range current.names {
+		if key != name {
+			next.names = append(next.names, key)
+			next.matchers[key] = current.matchers[key]
+		}
+	}
+
+	o.groups.Store(next)
+	return true
+}
+
+// RouteGroups returns the names of the route groups in o, in the order they are attempted.
+func (o *Router) RouteGroups() []string {
+	groups := o.loadGroups()
+	if groups == nil {
+		return nil
+	}
+
+	return append([]string{}, groups.names...)
+}
+
+func (o *Router) loadGroups() *routeGroups {
+	groups, _ := o.groups.Load().(*routeGroups)
+	return groups
 }
@@ -0,0 +1,169 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterServeHTTP(t *testing.T) {
+	rm := RouteMap{
+		"/products": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}),
+		},
+	}
+
+	r := NewRouter(rm.StringMatch())
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, NewRequest("GET", "/products"))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, NewRequest("GET", "/product"))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestRouterMiddleware(t *testing.T) {
+	var order []string
+	rm := RouteMap{
+		"/products": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, "handler")
+			}),
+		},
+	}
+
+	r := NewRouter(rm.StringMatch())
+	r.Middleware = NewChain(recordingMiddleware(&order, "a"), recordingMiddleware(&order, "b"))
+	r.MatchMiddleware = NewChain(recordingMiddleware(&order, "match"))
+	r.NotFound = func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "not found")
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), NewRequest("GET", "/products"))
+	assert.Equal(t, []string{"a before", "b before", "match before", "handler", "match after", "b after", "a after"}, order)
+
+	order = nil
+	r.ServeHTTP(httptest.NewRecorder(), NewRequest("GET", "/missing"))
+	assert.Equal(t, []string{"a before", "b before", "not found", "b after", "a after"}, order)
+}
+
+func TestRouterRouteGroups(t *testing.T) {
+	newRouteMap := func(pattern, body string) RouteMap {
+		return RouteMap{
+			pattern: MethodHandlers{
+				http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(body))
+				}),
+			},
+		}
+	}
+
+	r := NewRouter(newRouteMap("/static", "static").StringMatch())
+	r.SetRoutes("a", newRouteMap("/tenants/a", "a").StringMatch())
+	r.SetRoutes("b", newRouteMap("/tenants/b", "b").StringMatch())
+	assert.Equal(t, []string{"a", "b"}, r.RouteGroups())
+
+	serve := func(path string) (int, string) {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, NewRequest("GET", path))
+		return recorder.Code, recorder.Body.String()
+	}
+
+	cases := map[string]struct {
+		Path string
+		Body string
+	}{
+		"Static":   {Path: "/static", Body: "static"},
+		"Tenant A": {Path: "/tenants/a", Body: "a"},
+		"Tenant B": {Path: "/tenants/b", Body: "b"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			status, body := serve(c.Path)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, c.Body, body)
+		})
+	}
+
+	r.SetRoutes("a", newRouteMap("/tenants/a", "a2").StringMatch())
+	assert.Equal(t, []string{"a", "b"}, r.RouteGroups())
+	_, body := serve("/tenants/a")
+	assert.Equal(t, "a2", body)
+
+	assert.True(t, r.RemoveRoutes("a"))
+	assert.False(t, r.RemoveRoutes("a"))
+	assert.Equal(t, []string{"b"}, r.RouteGroups())
+	status, _ := serve("/tenants/a")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestRouterRouteGroupsConcurrent(t *testing.T) {
+	rm := RouteMap{
+		"/tenants/:tenantID": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		},
+	}
+
+	r := NewRouter(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(3)
+		name := fmt.Sprintf("tenant-%d", i%5)
+		go func() {
+			defer wg.Done()
+			r.SetRoutes(name, rm.VariableMatch())
+		}()
+
+		go func() {
+			defer wg.Done()
+			r.RemoveRoutes(name)
+		}()
+
+		go func() {
+			defer wg.Done()
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, NewRequest("GET", "/tenants/a"))
+			if recorder.Code != http.StatusOK && recorder.Code != http.StatusNotFound {
+				t.Errorf("unexpected status %d", recorder.Code)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestRouterMiddlewareWrappedOnce(t *testing.T) {
+	rm := RouteMap{
+		"/products": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		},
+	}
+
+	var wrapped int
+	counting := func(handler http.Handler) http.Handler {
+		wrapped++
+		return handler
+	}
+
+	r := NewRouter(rm.StringMatch())
+	r.Middleware = NewChain(counting)
+	r.MatchMiddleware = NewChain(counting)
+	for i := 0; i < 3; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), NewRequest("GET", "/products"))
+	}
+
+	assert.Equal(t, 2, wrapped)
+
+	r.MatchMiddleware = NewChain(counting)
+	r.ServeHTTP(httptest.NewRecorder(), NewRequest("GET", "/products"))
+	assert.Equal(t, 3, wrapped)
+}
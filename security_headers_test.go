@@ -0,0 +1,58 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := CSPNonce(r)
+		assert.False(t, ok)
+	})
+
+	recorder := httptest.NewRecorder()
+	SecurityHeadersMiddleware(DefaultSecurityHeadersConfig())(handler).ServeHTTP(recorder, NewRequest("GET", "/"))
+
+	assert.Equal(t, "max-age=31536000; includeSubDomains", recorder.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "default-src 'self'; frame-ancestors 'none'; object-src 'none'", recorder.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "nosniff", recorder.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", recorder.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", recorder.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "camera=(), geolocation=(), microphone=()", recorder.Header().Get("Permissions-Policy"))
+}
+
+func TestSecurityHeadersMiddlewareOptions(t *testing.T) {
+	var nonces []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce, ok := CSPNonce(r)
+		assert.True(t, ok)
+		nonces = append(nonces, nonce)
+	})
+
+	config := SecurityHeadersConfig{
+		HSTSMaxAge:            time.Hour,
+		HSTSIncludeSubDomains: true,
+		HSTSPreload:           true,
+		ContentSecurityPolicy: "script-src 'nonce-{nonce}'; style-src 'nonce-{nonce}'",
+	}
+
+	middleware := SecurityHeadersMiddleware(config)(handler)
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
+		middleware.ServeHTTP(recorder, NewRequest("GET", "/"))
+
+		nonce := nonces[i]
+		assert.NotEmpty(t, nonce)
+		assert.Equal(t, "max-age=3600; includeSubDomains; preload", recorder.Header().Get("Strict-Transport-Security"))
+		assert.Equal(t, "script-src 'nonce-"+nonce+"'; style-src 'nonce-"+nonce+"'", recorder.Header().Get("Content-Security-Policy"))
+		assert.Equal(t, "", recorder.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "", recorder.Header().Get("X-Content-Type-Options"))
+	}
+
+	assert.NotEqual(t, nonces[0], nonces[1])
+}
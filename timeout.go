@@ -0,0 +1,129 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TimeoutMiddleware returns a Middleware that bounds the time a handler may run.
+// The request's context is given a deadline of timeout.
+// If the handler does not finish before the deadline, a response with the
+// specified status (typically 503 Service Unavailable or 504 Gateway Timeout)
+// and body is returned, and any later writes from the handler fail with http.ErrHandlerTimeout.
+// Handlers should watch r.Context().Done() to stop work early.
+//
+// If the client disconnects before the deadline, no response is written.
+//
+// When timeouts are nested, the shortest deadline wins,
+// so different timeouts should be applied to disjoint sets of routes,
+// using RouteMap.ApplyMiddlewareIf or separate RouteMaps:
+//   slow := PatternFilter("/reports*")
+//   rm.ApplyMiddlewareIf(slow, TimeoutMiddleware(time.Minute, http.StatusGatewayTimeout, ""))
+//   rm.ApplyMiddlewareIf(func(pattern, method string) bool { return !slow(pattern, method) },
+//           TimeoutMiddleware(5*time.Second, http.StatusServiceUnavailable, "timeout\n"))
+func TimeoutMiddleware(timeout time.Duration, status int, body string) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{
+				w:      w,
+				header: http.Header{},
+			}
+
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+
+				handler.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				dst := w.Header()
+				for key, values := range tw.header {
+					dst[key] = values
+				}
+
+				if !tw.wroteHeader {
+					tw.code = http.StatusOK
+				}
+
+				w.WriteHeader(tw.code)
+				w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				tw.timedOut = true
+				if ctx.Err() == context.DeadlineExceeded {
+					w.WriteHeader(status)
+					w.Write([]byte(body))
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a handler's response so that it can be discarded
+// if the handler does not finish in time.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	buf    bytes.Buffer
+
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+	code        int
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	if !tw.wroteHeader {
+		tw.writeHeader(http.StatusOK)
+	}
+
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+
+	tw.writeHeader(code)
+}
+
+func (tw *timeoutWriter) writeHeader(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
@@ -0,0 +1,87 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline := r.Context().Deadline()
+		assert.True(t, hasDeadline)
+
+		w.Header().Set("X-Test", "value")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("done"))
+	})
+
+	recorder := httptest.NewRecorder()
+	r := NewRequest("GET", "/")
+
+	TimeoutMiddleware(time.Second, http.StatusServiceUnavailable, "timeout")(handler).ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "value", recorder.Header().Get("X-Test"))
+	assert.Equal(t, "done", recorder.Body.String())
+}
+
+func TestTimeoutMiddlewareTimeout(t *testing.T) {
+	lateWrite := make(chan error, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(time.Millisecond * 10)
+		_, err := w.Write([]byte("late"))
+		lateWrite <- err
+	})
+
+	recorder := httptest.NewRecorder()
+	r := NewRequest("GET", "/")
+
+	TimeoutMiddleware(time.Millisecond, http.StatusGatewayTimeout, "timeout")(handler).ServeHTTP(recorder, r)
+	assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+	assert.Equal(t, "timeout", recorder.Body.String())
+	assert.Equal(t, http.ErrHandlerTimeout, <-lateWrite)
+	assert.Equal(t, "timeout", recorder.Body.String())
+}
+
+func TestTimeoutMiddlewareNested(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	outer := TimeoutMiddleware(time.Hour, http.StatusServiceUnavailable, "outer")
+	inner := TimeoutMiddleware(time.Millisecond, http.StatusServiceUnavailable, "inner")
+
+	recorder := httptest.NewRecorder()
+	outer(inner(handler)).ServeHTTP(recorder, NewRequest("GET", "/"))
+	assert.Equal(t, "inner", recorder.Body.String())
+}
+
+func TestTimeoutMiddlewarePanic(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	recorder := httptest.NewRecorder()
+	assert.PanicsWithValue(t, "boom", func() {
+		TimeoutMiddleware(time.Second, http.StatusServiceUnavailable, "")(handler).ServeHTTP(recorder, NewRequest("GET", "/"))
+	})
+}
+
+func TestTimeoutMiddlewareClientDisconnect(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	recorder := httptest.NewRecorder()
+	r := NewRequest("GET", "/").WithContext(ctx)
+	TimeoutMiddleware(time.Hour, http.StatusGatewayTimeout, "timeout")(handler).ServeHTTP(recorder, r)
+	assert.Equal(t, "", recorder.Body.String())
+}
@@ -0,0 +1,266 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanContext identifies a span within a trace, as described by the W3C Trace Context specification.
+type SpanContext struct {
+	// TraceID is the 32 character lowercase hex ID of the trace.
+	TraceID string
+	// SpanID is the 16 character lowercase hex ID of the span.
+	SpanID string
+	// Flags are the trace flags, such as 0x01 for sampled.
+	Flags byte
+	// TraceState is the vendor-specific tracestate list, with invalid list-members removed.
+	TraceState string
+}
+
+// Traceparent returns the traceparent header value for sc.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// Inject sets the traceparent and tracestate headers in h,
+// which propagates the trace to outgoing requests.
+func (sc SpanContext) Inject(h http.Header) {
+	h.Set("traceparent", sc.Traceparent())
+	if sc.TraceState != "" {
+		h.Set("tracestate", sc.TraceState)
+	}
+}
+
+// Span is a finished span for a single request.
+type Span struct {
+	SpanContext
+	// ParentSpanID is the ID of the caller's span, or "" if the request started a new trace.
+	ParentSpanID string
+	// Name is the request's method and matched route pattern, such as "GET /products/:productID".
+	// If no route was matched, Name is the request's method.
+	Name   string
+	Method string
+	Path   string
+	Route  string
+	Status int
+	Start  time.Time
+	End    time.Time
+}
+
+// A SpanExporter exports finished spans.
+type SpanExporter interface {
+	ExportSpan(span Span)
+}
+
+// InMemoryExporter is a SpanExporter that keeps finished spans in memory.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []Span
+}
+
+// ExportSpan adds span to e.
+func (e *InMemoryExporter) ExportSpan(span Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the spans exported to e.
+func (e *InMemoryExporter) Spans() []Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]Span{}, e.spans...)
+}
+
+// TracingMiddleware returns a Middleware that continues the trace from requests'
+// traceparent and tracestate headers, or starts a new trace if they are missing or invalid.
+// A child span is created for each request; its SpanContext can be fetched using CurrentSpan,
+// and the span is exported to exporter once the request has been served.
+// TracingMiddleware can be used as Router.Middleware, in which case requests
+// handled by Router.NotFound are traced too.
+func TracingMiddleware(exporter SpanExporter) Middleware {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			span := Span{
+				Method: r.Method,
+				Path:   r.URL.Path,
+				Start:  time.Now(),
+			}
+
+			if parent, ok := parseTraceparent(r.Header.Get("traceparent")); ok {
+				span.TraceID = parent.TraceID
+				span.ParentSpanID = parent.SpanID
+				span.Flags = parent.Flags
+				span.TraceState = parseTracestate(r.Header.Values("tracestate"))
+			} else {
+				span.TraceID = randomHex(16)
+				span.Flags = 0x01
+			}
+
+			span.SpanID = randomHex(8)
+
+			r, recorder := recordRoute(r)
+			r = r.WithContext(context.WithValue(r.Context(), spanContextKey, span.SpanContext))
+			sw := newStatusWriter(w)
+			defer func() {
+				span.End = time.Now()
+				span.Status = sw.Status()
+				span.Name = r.Method
+				route, ok := recorder.Route()
+				if !ok {
+					// the route was matched before TracingMiddleware, such as when it is applied using RouteMap.ApplyMiddleware
+					route, ok = MatchedRoute(r)
+				}
+
+				if ok {
+					span.Route = route.Pattern
+					span.Name = r.Method + " " + route.Pattern
+				}
+
+				exporter.ExportSpan(span)
+			}()
+
+			handler.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// CurrentSpan returns the SpanContext of the span created for r by TracingMiddleware.
+func CurrentSpan(r *http.Request) (SpanContext, bool) {
+	sc, ok := r.Context().Value(spanContextKey).(SpanContext)
+	return sc, ok
+}
+
+// parseTraceparent parses a traceparent header value.
+func parseTraceparent(traceparent string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+
+	if !isLowerHex(traceID, 32) || traceID == strings.Repeat("0", 32) {
+		return SpanContext{}, false
+	}
+
+	if !isLowerHex(spanID, 16) || spanID == strings.Repeat("0", 16) {
+		return SpanContext{}, false
+	}
+
+	if !isLowerHex(flags, 2) {
+		return SpanContext{}, false
+	}
+
+	b, _ := hex.DecodeString(flags)
+	return SpanContext{TraceID: traceID, SpanID: spanID, Flags: b[0]}, true
+}
+
+// maxTracestateMembers is the maximum number of list-members in a tracestate header.
+const maxTracestateMembers = 32
+
+// parseTracestate combines the values of the tracestate headers in values into a single list,
+// as described by the W3C Trace Context specification.
+// Invalid and duplicate list-members are dropped, and at most 32 list-members are kept.
+func parseTracestate(values []string) string {
+	members := []string{}
+	seen := map[string]bool{}
+	for _, member := range splitHeaderList(values) {
+		if len(members) == maxTracestateMembers {
+			break
+		}
+
+		parts := strings.SplitN(member, "=", 2)
+		if len(parts) != 2 || !isTracestateKey(parts[0]) || !isTracestateValue(parts[1]) || seen[parts[0]] {
+			continue
+		}
+
+		seen[parts[0]] = true
+		members = append(members, member)
+	}
+
+	return strings.Join(members, ",")
+}
+
+// isTracestateKey reports whether key is a valid simple-key or multi-tenant-key.
+func isTracestateKey(key string) bool {
+	if i := strings.Index(key, "@"); i >= 0 {
+		tenant, system := key[:i], key[i+1:]
+		return len(tenant) <= 241 && len(system) <= 14 &&
+			isTracestateKeyPart(tenant, true) && isTracestateKeyPart(system, false)
+	}
+
+	return len(key) <= 256 && isTracestateKeyPart(key, false)
+}
+
+// isTracestateKeyPart reports whether s starts with a lowercase letter, or a digit if allowDigit is set,
+// and contains only lowercase letters, digits, and the characters _-*/.
+func isTracestateKeyPart(s string, allowDigit bool) bool {
+	if s == "" {
+		return false
+	}
+
+	for i, c := range s {
+		lower := c >= 'a' && c <= 'z'
+		digit := c >= '0' && c <= '9'
+		if i == 0 && !lower && !(allowDigit && digit) {
+			return false
+		}
+
+		if !lower && !digit && !strings.ContainsRune("_-*/", c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isTracestateValue reports whether value contains only printable ASCII characters other than ',' and '=',
+// up to 256 characters, and does not end with a space.
+func isTracestateValue(value string) bool {
+	if value == "" || len(value) > 256 || strings.HasSuffix(value, " ") {
+		return false
+	}
+
+	for _, c := range value {
+		if c < 0x20 || c > 0x7e || c == ',' || c == '=' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isLowerHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,186 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	cases := map[string]struct {
+		Traceparent string
+		Valid       bool
+	}{
+		"Valid": {
+			Traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			Valid:       true,
+		},
+		"Future version with extra fields": {
+			Traceparent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			Valid:       true,
+		},
+		"Version 00 with extra fields": {
+			Traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		},
+		"Invalid version": {
+			Traceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		"Uppercase": {
+			Traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		},
+		"Zero trace ID": {
+			Traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		"Zero span ID": {
+			Traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		},
+		"Short span ID": {
+			Traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902-01",
+		},
+		"Empty": {
+			Traceparent: "",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, ok := parseTraceparent(c.Traceparent)
+			assert.Equal(t, c.Valid, ok)
+		})
+	}
+}
+
+func TestParseTracestate(t *testing.T) {
+	tooMany := []string{}
+	for i := 0; i < 40; i++ {
+		tooMany = append(tooMany, fmt.Sprintf("k%d=v", i))
+	}
+
+	cases := map[string]struct {
+		Values   []string
+		Expected string
+	}{
+		"Single": {
+			Values:   []string{"vendor=value"},
+			Expected: "vendor=value",
+		},
+		"Multiple headers": {
+			Values:   []string{"a=1, b=2", "c=3"},
+			Expected: "a=1,b=2,c=3",
+		},
+		"Multi-tenant key": {
+			Values:   []string{"1tenant@system=v"},
+			Expected: "1tenant@system=v",
+		},
+		"Invalid members": {
+			Values:   []string{"Upper=1, noequals, a=1, 1digit=2, b=has=equals, c=", "d=ok"},
+			Expected: "a=1,d=ok",
+		},
+		"Duplicate keys": {
+			Values:   []string{"a=1,a=2"},
+			Expected: "a=1",
+		},
+		"Too many members": {
+			Values:   []string{strings.Join(tooMany, ",")},
+			Expected: strings.Join(tooMany[:32], ","),
+		},
+		"Empty": {
+			Expected: "",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.Expected, parseTracestate(c.Values))
+		})
+	}
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := &InMemoryExporter{}
+
+	var current SpanContext
+	rm := RouteMap{
+		"/products/:productID": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				current, _ = CurrentSpan(r)
+				w.WriteHeader(http.StatusAccepted)
+			}),
+		},
+	}
+
+	router := NewRouter(rm.VariableMatch())
+	router.Middleware = NewChain(TracingMiddleware(exporter))
+
+	r := NewRequest("GET", "/products/p1")
+	r.Header = http.Header{}
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set("tracestate", "vendor=value")
+	r.Header.Add("tracestate", "Invalid=value")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := exporter.Spans()
+	if assert.Len(t, spans, 1) {
+		span := spans[0]
+		assert.Equal(t, "GET /products/:productID", span.Name)
+		assert.Equal(t, "/products/:productID", span.Route)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", span.ParentSpanID)
+		assert.Equal(t, "vendor=value", span.TraceState)
+		assert.Equal(t, http.StatusAccepted, span.Status)
+		assert.Len(t, span.SpanID, 16)
+		assert.NotEqual(t, span.ParentSpanID, span.SpanID)
+		assert.Equal(t, span.SpanContext, current)
+		assert.False(t, span.End.Before(span.Start))
+	}
+
+	header := http.Header{}
+	current.Inject(header)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+current.SpanID+"-01", header.Get("traceparent"))
+	assert.Equal(t, "vendor=value", header.Get("tracestate"))
+}
+
+func TestTracingMiddlewareNewTrace(t *testing.T) {
+	exporter := &InMemoryExporter{}
+	router := NewRouter(nil)
+	router.Middleware = NewChain(TracingMiddleware(exporter))
+
+	r := NewRequest("GET", "/missing")
+	r.Header = http.Header{}
+	r.Header.Set("traceparent", "invalid")
+	r.Header.Set("tracestate", "vendor=value")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := exporter.Spans()
+	if assert.Len(t, spans, 1) {
+		span := spans[0]
+		assert.Equal(t, "GET", span.Name)
+		assert.Equal(t, http.StatusNotFound, span.Status)
+		assert.Len(t, span.TraceID, 32)
+		assert.Equal(t, "", span.ParentSpanID)
+		assert.Equal(t, "", span.TraceState)
+	}
+}
+
+func TestTracingMiddlewareRouteMap(t *testing.T) {
+	exporter := &InMemoryExporter{}
+	rm := RouteMap{
+		"/p/:id": MethodHandlers{
+			http.MethodGet: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		},
+	}
+
+	rm.ApplyMiddleware(TracingMiddleware(exporter))
+	router := NewRouter(rm.VariableMatch())
+	router.ServeHTTP(httptest.NewRecorder(), NewRequest("GET", "/p/1"))
+
+	spans := exporter.Spans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "GET /p/:id", spans[0].Name)
+		assert.Equal(t, "/p/:id", spans[0].Route)
+	}
+}